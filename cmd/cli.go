@@ -1,15 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"os/signal"
 	"runtime/debug"
+	"syscall"
 
 	"github.com/lmittmann/tint"
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/pflag"
+	"github.com/thiagokokada/gh-gfm-preview/internal/app"
 	"github.com/thiagokokada/gh-gfm-preview/internal/server"
 )
 
@@ -21,14 +25,37 @@ func Execute() {
 
 	port := fs.IntP("port", "p", 3333, "TCP port number of this server")
 	host := fs.StringP("host", "H", "localhost", "hostname this server will bind")
+	socket := fs.StringP("unix-socket", "", "", "listen on a Unix socket at this path instead of TCP")
+	basePath := fs.StringP("base-path", "", "", `serve everything under this URL prefix, e.g. "/preview/" behind a reverse proxy`)
+	token := fs.StringP("token", "", "", "access token clients must present (default random when the host is not a loopback address)")
+	disableToken := fs.BoolP("disable-token", "", false, "do not require an access token, even when the host is not a loopback address")
+	allowedHosts := fs.StringArrayP("allowed-host", "", nil, `additional name accepted in the Host header, e.g. of a reverse proxy, or "*" for any (can be repeated)`)
+	useTLS := fs.BoolP("tls", "", false, "serve over HTTPS, with a generated self-signed certificate unless --tls-cert and --tls-key are given")
+	tlsCert := fs.StringP("tls-cert", "", "", "PEM certificate file used with --tls")
+	tlsKey := fs.StringP("tls-key", "", "", "PEM private key file used with --tls")
 	disableReload := fs.BoolP("disable-reload", "R", false, "disable live reloading")
 	disableAutoOpen := fs.BoolP("disable-auto-open", "A", false, "disable auto opening your browser")
 	lightMode := fs.BoolP("light-mode", "l", false, "force light mode")
 	darkMode := fs.BoolP("dark-mode", "d", false, "force dark mode")
 	markdownMode := fs.BoolP("markdown-mode", "m", false, `force "markdown" mode (rather than default "gfm")`)
 	directoryListing := fs.BoolP("directory-listing", "D", false, "enable directory browsing mode")
-	directoryListingShowExtensions := fs.StringP("directory-listing-show-extensions", "", ".md,.txt", "file extensions to show in directory listing (comma-separated, use '*' for all files)")
+	directoryListingShowExtensions := fs.StringP("directory-listing-show-extensions", "", ".md,.txt,.rst,.adoc,.org,.csv,.tsv,.ipynb", "file extensions to show in directory listing (comma-separated, use '*' for all files)")
 	directoryListingTextExtensions := fs.StringP("directory-listing-text-extensions", "", ".md,.txt", "text file extensions for preview (comma-separated, others will be served as binary)")
+	ref := fs.StringP("ref", "", "", "preview files as of a git commit, branch or tag instead of the working tree")
+	wiki := fs.BoolP("wiki", "", false, "preview a GitHub wiki: [[wiki links]], _Sidebar and _Footer pages (default for directories ending in .wiki)")
+	autolink := fs.BoolP("autolink", "", false, "autolink issue, commit and mention references (without network access)")
+	autolinkRepository := fs.StringP("autolink-repository", "", "", `repository ("owner/name") used for autolinks (default detected from git remote)`)
+	interactiveTasks := fs.BoolP("interactive-tasks", "", false, "allow toggling task list checkboxes, writing changes back to the file")
+	codeRenderers := fs.StringArrayP("code-renderer", "", nil, `render fenced code blocks of a language as SVG with a command reading stdin, e.g. "dot=dot -Tsvg" (can be repeated)`)
+	codeRendererTimeout := fs.DurationP("code-renderer-timeout", "", app.DefaultCodeRendererTimeout, "maximum time a code or markup renderer command may run")
+	markupRenderers := fs.StringArrayP("markup-renderer", "", nil, `render files with an extension as HTML with a command reading stdin, e.g. ".rst=pandoc -f rst" (can be repeated)`)
+	codeLightStyle := fs.StringP("code-light-style", "", "github", "syntax highlighting style used in light mode (any chroma style)")
+	codeDarkStyle := fs.StringP("code-dark-style", "", "github-dark", "syntax highlighting style used in dark mode (any chroma style)")
+	codeLineNumbers := fs.BoolP("code-line-numbers", "", false, "show line numbers in code blocks")
+	exitAfterDisconnect := fs.DurationP("exit-after-disconnect", "", 0, "exit once no browser has been connected for this long after the last one disconnected (0 disables)")
+	exitIdleTimeout := fs.DurationP("exit-idle-timeout", "", 0, "exit if no browser connects within this time after starting (0 disables)")
+	customCSS := fs.StringP("css", "", "", "additional stylesheet to include in the preview (reloaded on changes)")
+	customTemplate := fs.StringP("template", "", "", "replacement for the built-in HTML template")
 	noColor := fs.BoolP("no-color", "", false, "disable color for logs")
 	verbose := fs.BoolP("verbose", "v", false, "show verbose output")
 	version := fs.BoolP("version", "", false, "show program version")
@@ -67,6 +94,14 @@ func Execute() {
 		Filename:                       filename,
 		MarkdownMode:                   *markdownMode,
 		Reload:                         !*disableReload,
+		Token:                          *token,
+		DisableToken:                   *disableToken,
+		AllowedHosts:                   *allowedHosts,
+		TLS:                            *useTLS || *tlsCert != "" || *tlsKey != "",
+		TLSCert:                        *tlsCert,
+		TLSKey:                         *tlsKey,
+		BasePath:                       *basePath,
+		Version:                        getVersion(),
 		ForceLightMode:                 *lightMode,
 		ForceDarkMode:                  *darkMode,
 		AutoOpen:                       !*disableAutoOpen,
@@ -75,11 +110,31 @@ func Execute() {
 		DirectoryListing:               *directoryListing,
 		DirectoryListingShowExtensions: *directoryListingShowExtensions,
 		DirectoryListingTextExtensions: *directoryListingTextExtensions,
+		Ref:                            *ref,
+		Wiki:                           *wiki,
+		Autolink:                       *autolink,
+		AutolinkRepository:             *autolinkRepository,
+		InteractiveTasks:               *interactiveTasks,
+		CodeRenderers:                  *codeRenderers,
+		CodeRendererTimeout:            *codeRendererTimeout,
+		MarkupRenderers:                *markupRenderers,
+		CodeLightStyle:                 *codeLightStyle,
+		CodeDarkStyle:                  *codeDarkStyle,
+		CodeLineNumbers:                *codeLineNumbers,
+		ExitAfterDisconnect:            *exitAfterDisconnect,
+		ExitIdleTimeout:                *exitIdleTimeout,
+		CustomCSS:                      *customCSS,
+		CustomTemplate:                 *customTemplate,
 	}
 
-	httpServer := server.Server{Host: *host, Port: *port}
+	httpServer := server.Server{Host: *host, Port: *port, Socket: *socket}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err := httpServer.Serve(ctx, param)
+
+	stop()
 
-	err := httpServer.Serve(param)
 	if err != nil {
 		slog.Error("Error while starting HTTP server", "error", err)
 		os.Exit(1)
@@ -9,12 +9,14 @@ require (
 	github.com/gorilla/websocket v1.5.3
 	github.com/lmittmann/tint v1.2.0
 	github.com/mattn/go-isatty v0.0.23
+	github.com/niklasfasching/go-org v1.9.1
 	github.com/spf13/pflag v1.0.10
 	github.com/thiagokokada/goldmark-gh-alerts v0.0.0-20250302164040-cf407c0ddfaf
 	github.com/yuin/goldmark v1.8.4
 	github.com/yuin/goldmark-emoji v1.0.6
 	github.com/yuin/goldmark-highlighting/v2 v2.0.0-20230729083705-37449abec8cc
 	go.abhg.dev/goldmark/anchor v0.2.0
+	golang.org/x/net v0.38.0
 	golang.org/x/text v0.40.0
 )
 
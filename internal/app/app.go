@@ -11,6 +11,8 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 
 	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
 	"github.com/andybalholm/crlf"
@@ -42,6 +44,29 @@ var (
 
 var ErrFileNotFound = errors.New("file not found")
 
+// Option configures optional rendering features of ToHTML.
+type Option func(*options)
+
+type options struct {
+	autolink         bool
+	repository       string
+	interactiveTasks bool
+	codeRenderers    *CodeRenderers
+	lineNumbers      bool
+	wiki             *WikiPages
+	wikiBasePath     string
+}
+
+// WithReferenceLinks enables autolinking of issue, pull request, commit and
+// mention references. repository is the "owner/name" slug used for short
+// references such as #123 and may be empty.
+func WithReferenceLinks(repository string) Option {
+	return func(o *options) {
+		o.autolink = true
+		o.repository = repository
+	}
+}
+
 func TargetFile(filename string) (string, error) {
 	var err error
 
@@ -66,12 +91,47 @@ func TargetFile(filename string) (string, error) {
 	return filename, err
 }
 
-func ToHTML(markdown string, isMarkdownMode bool) (string, error) {
-	var extensions goldmark.Option
-	if isMarkdownMode {
-		extensions = goldmark.WithExtensions()
-	} else {
-		extensions = goldmark.WithExtensions(
+// WithInteractiveTasks renders task list checkboxes enabled and annotated with
+// their source line, see ToggleTask.
+func WithInteractiveTasks() Option {
+	return func(o *options) {
+		o.interactiveTasks = true
+	}
+}
+
+// WithCodeRenderers renders fenced code blocks of the languages configured in
+// renderers as inline SVG.
+func WithCodeRenderers(renderers *CodeRenderers) Option {
+	return func(o *options) {
+		o.codeRenderers = renderers
+	}
+}
+
+// WithLineNumbers shows line numbers in highlighted code blocks.
+func WithLineNumbers() Option {
+	return func(o *options) {
+		o.lineNumbers = true
+	}
+}
+
+// WithWikiLinks renders [[Page Name]] and [[Link text|Page Name]] wiki links
+// to the pages of wiki, with absolute links under basePath.
+func WithWikiLinks(wiki *WikiPages, basePath string) Option {
+	return func(o *options) {
+		o.wiki = wiki
+		o.wikiBasePath = basePath
+	}
+}
+
+func ToHTML(markdown string, isMarkdownMode bool, opts ...Option) (string, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var extensions []goldmark.Extender
+	if !isMarkdownMode {
+		extensions = append(extensions,
 			&alerts.GhAlerts{Icons: alertIconMap},
 			&anchor.Extender{
 				Texter: anchor.Text(anchorIcon),
@@ -81,16 +141,29 @@ func ToHTML(markdown string, isMarkdownMode bool) (string, error) {
 			extension.Footnote,
 			newFootnoteExtender(),
 			extension.GFM,
-			highlighting.NewHighlighting(
-				highlighting.WithFormatOptions(
-					chromahtml.WithClasses(true),
-				),
-			),
+			highlighting.NewHighlighting(highlightingOptions(o)...),
+			newHighlightLinesExtender(),
 		)
+
+		if o.autolink {
+			extensions = append(extensions, newReferenceLinkExtender(o.repository))
+		}
+
+		if o.wiki != nil {
+			extensions = append(extensions, newWikiLinkExtender(o.wiki, o.wikiBasePath))
+		}
+
+		if o.interactiveTasks {
+			extensions = append(extensions, newInteractiveTaskExtender())
+		}
+
+		if o.codeRenderers != nil {
+			extensions = append(extensions, newCodeRendererExtender(o.codeRenderers, highlightingOptions(o)))
+		}
 	}
 
 	md := goldmark.New(
-		extensions,
+		goldmark.WithExtensions(extensions...),
 		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
 		goldmark.WithRendererOptions(html.WithUnsafe()),
 	)
@@ -105,6 +178,15 @@ func ToHTML(markdown string, isMarkdownMode bool) (string, error) {
 	return buf.String(), nil
 }
 
+func highlightingOptions(o options) []highlighting.Option {
+	return []highlighting.Option{
+		highlighting.WithFormatOptions(
+			chromahtml.WithClasses(true),
+			chromahtml.WithLineNumbers(o.lineNumbers),
+		),
+	}
+}
+
 func Slurp(fileName string) (string, error) {
 	f, err := os.Open(fileName)
 	if err != nil {
@@ -130,6 +212,8 @@ func Slurp(fileName string) (string, error) {
 }
 
 // FindReadmeFS finds a README file in dir inside fsys and returns its path relative to dir.
+// When there are several, renderable variants (e.g. README.md or README.rst)
+// are preferred, see readmeExtensions.
 func FindReadmeFS(fsys fs.FS, dir string) (string, error) {
 	files, err := fs.ReadDir(fsys, dir)
 	if err != nil {
@@ -138,13 +222,47 @@ func FindReadmeFS(fsys fs.FS, dir string) (string, error) {
 		slog.Error("Read directory error", "dir", dir, "error", err)
 	}
 
+	readme := ""
+	bestRank := 0
+
 	for _, f := range files {
-		if readmePattern.MatchString(f.Name()) {
-			return path.Join(dir, f.Name()), nil
+		if !readmePattern.MatchString(f.Name()) {
+			continue
+		}
+
+		rank := readmeRank(f.Name())
+		if readme == "" || rank < bestRank {
+			readme, bestRank = f.Name(), rank
 		}
 	}
 
+	if readme != "" {
+		return path.Join(dir, readme), nil
+	}
+
 	err = fmt.Errorf("%w: README file in %s directory", ErrFileNotFound, dir)
 
 	return "", err
 }
+
+// readmeExtensions are the README extensions in order of preference.
+var readmeExtensions = slices.Concat(
+	markdownExtensions,
+	[]string{".rst", ".rest", ".adoc", ".asciidoc", ".asc", ".org", ".txt", ""},
+)
+
+// readmeRank orders README files by readmeExtensions, followed by any other
+// file starting with "readme".
+func readmeRank(name string) int {
+	ext := path.Ext(name)
+	if !strings.EqualFold(strings.TrimSuffix(name, ext), "readme") {
+		return len(readmeExtensions) + 1
+	}
+
+	i := slices.Index(readmeExtensions, strings.ToLower(ext))
+	if i < 0 {
+		return len(readmeExtensions)
+	}
+
+	return i
+}
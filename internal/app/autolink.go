@@ -0,0 +1,189 @@
+package app
+
+import (
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	ast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const githubURL = "https://github.com/"
+
+// referenceRegexp matches the references GitHub autolinks in Markdown:
+// [owner/repo]#123, GH-123, @user and full (40 characters) commit SHAs.
+var referenceRegexp = regexp.MustCompile(
+	`([A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9._-]+)?#([0-9]+)` +
+		`|GH-([0-9]+)` +
+		`|@([A-Za-z0-9](?:[A-Za-z0-9-]{0,37}[A-Za-z0-9])?)` +
+		`|\b([0-9a-f]{40})`,
+)
+
+type referenceLinkExtender struct {
+	repository string
+}
+
+// newReferenceLinkExtender returns an extension that renders issue, pull
+// request, commit and mention references as links to GitHub. repository is
+// the "owner/name" slug used for references that do not include one, and may
+// be empty, in which case only fully qualified references and mentions are
+// linked.
+func newReferenceLinkExtender(repository string) *referenceLinkExtender {
+	return &referenceLinkExtender{repository: repository}
+}
+
+func (e *referenceLinkExtender) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&referenceLinkTransformer{repository: e.repository}, 999),
+	))
+}
+
+type referenceLinkTransformer struct {
+	repository string
+}
+
+func (t *referenceLinkTransformer) Transform(doc *ast.Document, reader text.Reader, _ parser.Context) {
+	source := reader.Source()
+
+	var texts []*ast.Text
+
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := node.(type) {
+		case *ast.Link, *ast.AutoLink, *ast.CodeSpan, *ast.Image, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			texts = append(texts, n)
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	for _, n := range texts {
+		t.linkText(n, source)
+	}
+}
+
+func (t *referenceLinkTransformer) linkText(node *ast.Text, source []byte) {
+	segment := node.Segment
+	value := segment.Value(source)
+	parent := node.Parent()
+
+	if parent == nil {
+		return
+	}
+
+	var last ast.Node = node
+	start := 0
+
+	for _, m := range referenceRegexp.FindAllSubmatchIndex(value, -1) {
+		if !isReferenceBoundary(source, segment.Start+m[0], segment.Start+m[1]) {
+			continue
+		}
+
+		link := t.referenceLink(value, m)
+		if link == nil {
+			continue
+		}
+
+		before := ast.NewTextSegment(text.NewSegment(segment.Start+start, segment.Start+m[0]))
+		parent.InsertAfter(parent, last, before)
+		parent.InsertAfter(parent, before, link)
+		last = link
+		start = m[1]
+	}
+
+	if last == node {
+		return
+	}
+
+	after := ast.NewTextSegment(text.NewSegment(segment.Start+start, segment.Stop))
+	after.SetSoftLineBreak(node.SoftLineBreak())
+	after.SetHardLineBreak(node.HardLineBreak())
+	parent.InsertAfter(parent, last, after)
+	parent.RemoveChild(parent, node)
+}
+
+func (t *referenceLinkTransformer) referenceLink(value []byte, m []int) ast.Node {
+	label := value[m[0]:m[1]]
+
+	switch {
+	case m[4] >= 0: // [owner/repo]#123
+		repository := t.repository
+		if m[2] >= 0 {
+			repository = string(value[m[2]:m[3]])
+		}
+
+		if repository == "" {
+			return nil
+		}
+
+		return newReferenceLink(githubURL+repository+"/issues/"+string(value[m[4]:m[5]]), "issue-link js-issue-link", label)
+	case m[6] >= 0: // GH-123
+		if t.repository == "" {
+			return nil
+		}
+
+		return newReferenceLink(githubURL+t.repository+"/issues/"+string(value[m[6]:m[7]]), "issue-link js-issue-link", label)
+	case m[8] >= 0: // @user
+		return newReferenceLink(githubURL+string(value[m[8]:m[9]]), "user-mention notranslate", label)
+	case m[10] >= 0: // commit SHA
+		if t.repository == "" {
+			return nil
+		}
+
+		sha := value[m[10]:m[11]]
+		link := newReferenceLink(githubURL+t.repository+"/commit/"+string(sha), "commit-link", nil)
+
+		code := ast.NewString([]byte("<tt>" + string(sha[:7]) + "</tt>"))
+		code.SetCode(true)
+		link.AppendChild(link, code)
+
+		return link
+	}
+
+	return nil
+}
+
+func newReferenceLink(destination, class string, label []byte) *ast.Link {
+	link := ast.NewLink()
+	link.Destination = []byte(destination)
+	link.SetAttributeString("class", []byte(class))
+
+	if label != nil {
+		link.AppendChild(link, ast.NewString(label))
+	}
+
+	return link
+}
+
+// isReferenceBoundary reports whether source[start:stop] is not glued to
+// surrounding words, e.g. "foo#1" and "user@example" are not references.
+func isReferenceBoundary(source []byte, start, stop int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRune(source[:start])
+		if isReferenceRune(r) || r == '/' || r == '.' || r == '@' || r == '#' {
+			return false
+		}
+	}
+
+	if stop < len(source) {
+		r, _ := utf8.DecodeRune(source[stop:])
+		if isReferenceRune(r) || r == '/' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isReferenceRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
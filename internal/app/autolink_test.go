@@ -0,0 +1,88 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestReferenceLinks(t *testing.T) {
+	const sha = "4d0077f6a1b2c3d4e5f60718293a4b5c6d7e8f90"
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "Issue",
+			markdown: "Fixes #123.",
+			want:     `<p>Fixes <a href="https://github.com/owner/repo/issues/123" class="issue-link js-issue-link">#123</a>.</p>`,
+		},
+		{
+			name:     "Cross repository issue",
+			markdown: "See yuin/goldmark#42",
+			want:     `<p>See <a href="https://github.com/yuin/goldmark/issues/42" class="issue-link js-issue-link">yuin/goldmark#42</a></p>`,
+		},
+		{
+			name:     "GH prefixed issue",
+			markdown: "GH-7 is done",
+			want:     `<p><a href="https://github.com/owner/repo/issues/7" class="issue-link js-issue-link">GH-7</a> is done</p>`,
+		},
+		{
+			name:     "Mention",
+			markdown: "Thanks @octocat!",
+			want:     `<p>Thanks <a href="https://github.com/octocat" class="user-mention notranslate">@octocat</a>!</p>`,
+		},
+		{
+			name:     "Commit",
+			markdown: "Reverts " + sha,
+			want:     `<p>Reverts <a href="https://github.com/owner/repo/commit/` + sha + `" class="commit-link"><tt>4d0077f</tt></a></p>`,
+		},
+		{
+			name:     "Email is not a mention",
+			markdown: "Mail foo@example",
+			want:     `<p>Mail foo@example</p>`,
+		},
+		{
+			name:     "Code spans are ignored",
+			markdown: "`#123` and [#1](https://example.com)",
+			want:     `<p><code>#123</code> and <a href="https://example.com">#1</a></p>`,
+		},
+		{
+			name:     "Multiple references keep line breaks",
+			markdown: "#1 and #2\nnext",
+			want: `<p><a href="https://github.com/owner/repo/issues/1" class="issue-link js-issue-link">#1</a> and ` +
+				`<a href="https://github.com/owner/repo/issues/2" class="issue-link js-issue-link">#2</a>` + "\nnext</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, err := ToHTML(tt.markdown, false, WithReferenceLinks("owner/repo"))
+			assert.Nil(t, err)
+			assert.Equal(t, strings.TrimSpace(html), tt.want)
+		})
+	}
+}
+
+func TestReferenceLinksWithoutRepository(t *testing.T) {
+	html, err := ToHTML("#1 GH-2 @octocat owner/repo#3", false, WithReferenceLinks(""))
+	assert.Nil(t, err)
+
+	assert.False(t, strings.Contains(html, `/issues/1"`))
+	assert.False(t, strings.Contains(html, `/issues/2"`))
+	assert.True(t, strings.Contains(html, `href="https://github.com/octocat"`))
+	assert.True(t, strings.Contains(html, `href="https://github.com/owner/repo/issues/3"`))
+}
+
+func TestReferenceLinksAreOptIn(t *testing.T) {
+	html, err := ToHTML("#1 @octocat", false)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSpace(html), "<p>#1 @octocat</p>")
+
+	html, err = ToHTML("#1 @octocat", true, WithReferenceLinks("owner/repo"))
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSpace(html), "<p>#1 @octocat</p>")
+}
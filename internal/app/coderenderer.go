@@ -0,0 +1,246 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"html"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	ast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	// DefaultCodeRendererTimeout is the maximum time a renderer command may run.
+	DefaultCodeRendererTimeout = 10 * time.Second
+
+	// commandWaitDelay bounds the wait for the output of a renderer command
+	// after it is killed, since processes it started may still hold it open.
+	commandWaitDelay = time.Second
+
+	maxCodeRendererCacheEntries = 256
+)
+
+var (
+	ErrInvalidCodeRenderer = errors.New("invalid code renderer")
+	errCodeRendererTimeout = errors.New("renderer timed out")
+)
+
+// CodeRenderers renders fenced code blocks of configured languages by piping
+// their contents to external commands (e.g. Graphviz "dot -Tsvg"), caching the
+// resulting SVG by content hash.
+type CodeRenderers struct {
+	commands map[string][]string
+	timeout  time.Duration
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]string
+}
+
+// NewCodeRenderers parses specs in the "language=command args..." format.
+// Commands read the code block from stdin and write SVG to stdout.
+func NewCodeRenderers(specs []string, timeout time.Duration) (*CodeRenderers, error) {
+	if timeout <= 0 {
+		timeout = DefaultCodeRendererTimeout
+	}
+
+	commands := make(map[string][]string, len(specs))
+
+	for _, spec := range specs {
+		language, command, ok := strings.Cut(spec, "=")
+		language = strings.ToLower(strings.TrimSpace(language))
+		args := strings.Fields(command)
+
+		if !ok || language == "" || len(args) == 0 {
+			return nil, fmt.Errorf("%w: %q, expected language=command", ErrInvalidCodeRenderer, spec)
+		}
+
+		commands[language] = args
+	}
+
+	return &CodeRenderers{
+		commands: commands,
+		timeout:  timeout,
+		cache:    map[[sha256.Size]byte]string{},
+	}, nil
+}
+
+// Has reports whether there is a renderer for language.
+func (c *CodeRenderers) Has(language string) bool {
+	if c == nil {
+		return false
+	}
+
+	_, ok := c.commands[strings.ToLower(language)]
+
+	return ok
+}
+
+// Render returns the SVG output of the renderer command for language.
+func (c *CodeRenderers) Render(language string, source []byte) (string, error) {
+	args, ok := c.commands[strings.ToLower(language)]
+	if !ok {
+		return "", fmt.Errorf("%w: no renderer for %s", ErrInvalidCodeRenderer, language)
+	}
+
+	key := c.cacheKey(args, source)
+
+	c.mu.Lock()
+	svg, ok := c.cache[key]
+	c.mu.Unlock()
+
+	if ok {
+		return svg, nil
+	}
+
+	svg, err := c.run(args, source)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	if len(c.cache) >= maxCodeRendererCacheEntries {
+		clear(c.cache)
+	}
+
+	c.cache[key] = svg
+	c.mu.Unlock()
+
+	return svg, nil
+}
+
+func (c *CodeRenderers) cacheKey(args []string, source []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte(strings.Join(args, "\x00")))
+	h.Write([]byte{0})
+	h.Write(source)
+
+	var key [sha256.Size]byte
+
+	copy(key[:], h.Sum(nil))
+
+	return key
+}
+
+func (c *CodeRenderers) run(args []string, source []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // G204: commands are configured by the user
+	cmd.Stdin = bytes.NewReader(source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = commandWaitDelay
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%w after %s: %s", errCodeRendererTimeout, c.timeout, args[0])
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	out := stdout.String()
+
+	// Drop XML prolog and DOCTYPE, since the SVG is inlined in HTML
+	if i := strings.Index(out, "<svg"); i >= 0 {
+		out = out[i:]
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+type codeRendererExtender struct {
+	renderers *CodeRenderers
+	fallback  []highlighting.Option
+}
+
+// newCodeRendererExtender returns an extension that renders fenced code blocks
+// with renderers, using syntax highlighting configured by fallback for any
+// other language.
+func newCodeRendererExtender(renderers *CodeRenderers, fallback []highlighting.Option) *codeRendererExtender {
+	return &codeRendererExtender{renderers: renderers, fallback: fallback}
+}
+
+func (e *codeRendererExtender) Extend(m goldmark.Markdown) {
+	var capture nodeRendererFuncCapture
+
+	highlighting.NewHTMLRenderer(e.fallback...).RegisterFuncs(&capture)
+
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&codeRendererHTMLRenderer{renderers: e.renderers, fallback: capture.fn}, 100),
+	))
+}
+
+type nodeRendererFuncCapture struct {
+	fn renderer.NodeRendererFunc
+}
+
+func (c *nodeRendererFuncCapture) Register(_ ast.NodeKind, fn renderer.NodeRendererFunc) {
+	c.fn = fn
+}
+
+type codeRendererHTMLRenderer struct {
+	renderers *CodeRenderers
+	fallback  renderer.NodeRendererFunc
+}
+
+func (r *codeRendererHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+func (r *codeRendererHTMLRenderer) renderFencedCodeBlock(
+	w util.BufWriter, source []byte, node ast.Node, entering bool,
+) (ast.WalkStatus, error) {
+	n, ok := node.(*ast.FencedCodeBlock)
+	if !ok {
+		return ast.WalkStop, nil
+	}
+
+	language := string(n.Language(source))
+	if !r.renderers.Has(language) {
+		return r.fallback(w, source, node, entering)
+	}
+
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var code bytes.Buffer
+
+	for i := range n.Lines().Len() {
+		line := n.Lines().At(i)
+		code.Write(line.Value(source))
+	}
+
+	svg, err := r.renderers.Render(language, code.Bytes())
+	if err != nil {
+		_, _ = w.WriteString(`<div class="code-renderer-error"><p><strong>Unable to render `)
+		_, _ = w.WriteString(html.EscapeString(language))
+		_, _ = w.WriteString(` code block</strong></p><pre>`)
+		_, _ = w.WriteString(html.EscapeString(err.Error()))
+		_, _ = w.WriteString("</pre></div>\n")
+
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString(`<div class="code-renderer code-renderer-`)
+	_, _ = w.WriteString(html.EscapeString(strings.ToLower(language)))
+	_, _ = w.WriteString(`">`)
+	_, _ = w.WriteString(svg)
+	_, _ = w.WriteString("</div>\n")
+
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestNewCodeRenderers(t *testing.T) {
+	renderers, err := NewCodeRenderers([]string{"DOT=dot -Tsvg", " d2 = d2 - - "}, 0)
+	assert.Nil(t, err)
+
+	assert.True(t, renderers.Has("dot"))
+	assert.True(t, renderers.Has("D2"))
+	assert.False(t, renderers.Has("plantuml"))
+	assert.DeepEqual(t, renderers.commands["d2"], []string{"d2", "-", "-"})
+	assert.Equal(t, renderers.timeout, DefaultCodeRendererTimeout)
+
+	for _, spec := range []string{"dot", "=dot", "dot=", "dot=  "} {
+		_, err := NewCodeRenderers([]string{spec}, 0)
+		assert.True(t, errors.Is(err, ErrInvalidCodeRenderer))
+	}
+}
+
+func TestCodeRenderersInHTML(t *testing.T) {
+	renderers, err := NewCodeRenderers([]string{"svg=cat", "broken=false"}, time.Second)
+	assert.Nil(t, err)
+
+	markdown := "```svg\n<?xml version=\"1.0\"?>\n<svg><circle r=\"1\"/></svg>\n```\n\n" +
+		"```broken\ndigraph {}\n```\n\n" +
+		"```go\nfunc main() {}\n```\n"
+
+	html, err := ToHTML(markdown, false, WithCodeRenderers(renderers))
+	assert.Nil(t, err)
+
+	assert.True(t, strings.Contains(html, `<div class="code-renderer code-renderer-svg"><svg><circle r="1"/></svg></div>`))
+	assert.False(t, strings.Contains(html, "<?xml"))
+	assert.True(t, strings.Contains(html, `<div class="code-renderer-error"><p><strong>Unable to render broken code block</strong></p><pre>false: exit status 1`))
+	// Other languages are still highlighted
+	assert.True(t, strings.Contains(html, `<pre class="chroma">`))
+	assert.True(t, strings.Contains(html, `<span class="kd">func</span>`))
+}
+
+func TestCodeRenderersCache(t *testing.T) {
+	renderers, err := NewCodeRenderers([]string{"svg=cat"}, time.Second)
+	assert.Nil(t, err)
+
+	svg, err := renderers.Render("svg", []byte("<svg></svg>"))
+	assert.Nil(t, err)
+	assert.Equal(t, svg, "<svg></svg>")
+	assert.Equal(t, len(renderers.cache), 1)
+
+	// A cached result is used without running the command again
+	renderers.commands["svg"] = []string{"false"}
+	renderers.cache[renderers.cacheKey([]string{"false"}, []byte("<svg></svg>"))] = "<svg>cached</svg>"
+
+	svg, err = renderers.Render("svg", []byte("<svg></svg>"))
+	assert.Nil(t, err)
+	assert.Equal(t, svg, "<svg>cached</svg>")
+}
+
+func TestCodeRenderersTimeout(t *testing.T) {
+	renderers, err := NewCodeRenderers([]string{"slow=sleep 5"}, 50*time.Millisecond)
+	assert.Nil(t, err)
+
+	_, err = renderers.Render("slow", nil)
+	assert.True(t, errors.Is(err, errCodeRendererTimeout))
+	assert.Equal(t, len(renderers.cache), 0)
+}
+
+func TestCodeRenderersTimeoutWithChildProcess(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "render.sh")
+	assert.Nil(t, os.WriteFile(script, []byte("#!/bin/sh\nsleep 5 &\nsleep 5\n"), 0o700))
+
+	renderers, err := NewCodeRenderers([]string{"slow=" + script}, 50*time.Millisecond)
+	assert.Nil(t, err)
+
+	// the background sleep keeps stdout open after the script is killed
+	start := time.Now()
+	_, err = renderers.Render("slow", nil)
+	assert.True(t, errors.Is(err, errCodeRendererTimeout))
+	assert.True(t, time.Since(start) < 4*time.Second)
+}
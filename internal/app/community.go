@@ -0,0 +1,97 @@
+package app
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// CommunityFile is a community health file of a repository, such as its
+// license, shown in a tab of the repository landing page like on GitHub.
+type CommunityFile struct {
+	// Tab identifies the file in URLs, e.g. "license"
+	Tab string
+	// Name is the tab title, e.g. "License"
+	Name string
+	Path string
+}
+
+// communityFileKinds are the community health files in the order GitHub
+// shows their tabs, with the names (without extension) they can have.
+var communityFileKinds = []struct {
+	tab, name string
+	names     []string
+}{
+	{"code-of-conduct", "Code of conduct", []string{"code_of_conduct", "code-of-conduct"}},
+	{"contributing", "Contributing", []string{"contributing"}},
+	{"license", "License", []string{"license", "licence", "copying"}},
+	{"security", "Security", []string{"security"}},
+}
+
+// communityDirs are the directories of a repository searched for community
+// health files, by priority.
+var communityDirs = []string{".", ".github", "docs"}
+
+// FindCommunityFilesFS finds the community health files of the repository in
+// dir inside fsys.
+func FindCommunityFilesFS(fsys fs.FS, dir string) []CommunityFile {
+	var files []CommunityFile
+
+	for _, kind := range communityFileKinds {
+		for _, communityDir := range communityDirs {
+			file, ok := findCommunityFile(fsys, path.Join(dir, communityDir), kind.names)
+			if ok {
+				files = append(files, CommunityFile{Tab: kind.tab, Name: kind.name, Path: file})
+
+				break
+			}
+		}
+	}
+
+	return files
+}
+
+// findCommunityFile finds a file in dir called like one of names, with any
+// extension or suffix such as LICENSE-MIT.
+func findCommunityFile(fsys fs.FS, dir string, names []string) (string, bool) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return "", false
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		if hasCommunityFileName(entry.Name(), names) {
+			return path.Join(dir, entry.Name()), true
+		}
+	}
+
+	return "", false
+}
+
+// IsCommunityFile reports whether filePath is named like a community health
+// file, wherever it is.
+func IsCommunityFile(filePath string) bool {
+	for _, kind := range communityFileKinds {
+		if hasCommunityFileName(path.Base(filePath), kind.names) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasCommunityFileName(fileName string, names []string) bool {
+	base := strings.ToLower(fileName)
+
+	for _, name := range names {
+		if rest, ok := strings.CutPrefix(base, name); ok && (rest == "" || rest[0] == '.' || rest[0] == '-') {
+			return true
+		}
+	}
+
+	return false
+}
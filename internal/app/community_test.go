@@ -0,0 +1,38 @@
+package app
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestFindCommunityFilesFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md":                  {Data: []byte("readme")},
+		"LICENSE-MIT":                {Data: []byte("license")},
+		"licenses/other.txt":         {Data: []byte("not a license file")},
+		".github/CODE_OF_CONDUCT.md": {Data: []byte("conduct")},
+		"docs/SECURITY.md":           {Data: []byte("security")},
+		"docs/CONTRIBUTING.md":       {Data: []byte("docs contributing")},
+		"CONTRIBUTING.rst":           {Data: []byte("contributing")},
+		"contributors.md":            {Data: []byte("not contributing")},
+	}
+
+	assert.DeepEqual(t, FindCommunityFilesFS(fsys, "."), []CommunityFile{
+		{Tab: "code-of-conduct", Name: "Code of conduct", Path: ".github/CODE_OF_CONDUCT.md"},
+		{Tab: "contributing", Name: "Contributing", Path: "CONTRIBUTING.rst"},
+		{Tab: "license", Name: "License", Path: "LICENSE-MIT"},
+		{Tab: "security", Name: "Security", Path: "docs/SECURITY.md"},
+	})
+
+	assert.DeepEqual(t, FindCommunityFilesFS(fstest.MapFS{"README.md": {Data: []byte("readme")}}, "."), []CommunityFile(nil))
+}
+
+func TestIsCommunityFile(t *testing.T) {
+	assert.True(t, IsCommunityFile("LICENSE"))
+	assert.True(t, IsCommunityFile("docs/Copying.txt"))
+	assert.True(t, IsCommunityFile(".github/CODE_OF_CONDUCT.md"))
+	assert.False(t, IsCommunityFile("contributors.md"))
+	assert.False(t, IsCommunityFile("licenses/other.txt"))
+}
@@ -0,0 +1,299 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxDiffCells bounds the size of the table comparing the blocks of two
+// documents or the words of a changed block. Larger changes are shown as
+// removed and added blocks.
+const maxDiffCells = 4_000_000
+
+// inlineDiffTags are the blocks whose changes are highlighted word by word.
+var inlineDiffTags = []string{"p", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "dl", "blockquote"}
+
+// diffTokenRegexp splits HTML in tags, words and whitespace.
+var diffTokenRegexp = regexp.MustCompile(`<[^>]*>|[^<\s]+|\s+`)
+
+// adjacentMarkRegexps match consecutive marks, e.g. of words separated by a
+// space.
+var adjacentMarkRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`</ins>(\s*)<ins>`),
+	regexp.MustCompile(`</del>(\s*)<del>`),
+}
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffOp struct {
+	kind diffKind
+	a, b int
+}
+
+type htmlBlock struct {
+	tag  string
+	html string
+}
+
+// RichDiff compares two rendered documents like GitHub's rich diff: top level
+// blocks only in newHTML are marked as added, blocks only in oldHTML as
+// removed, and words changed inside paragraphs, headings, lists and quotes
+// are highlighted with <ins> and <del>.
+func RichDiff(oldHTML, newHTML string) (string, error) {
+	oldBlocks, err := htmlBlocks(oldHTML)
+	if err != nil {
+		return "", err
+	}
+
+	newBlocks, err := htmlBlocks(newHTML)
+	if err != nil {
+		return "", err
+	}
+
+	ops := diffSlices(blockHTML(oldBlocks), blockHTML(newBlocks))
+
+	var buf strings.Builder
+
+	buf.WriteString(`<div class="rich-diff">` + "\n")
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == diffEqual {
+			buf.WriteString(newBlocks[ops[i].b].html + "\n")
+			i++
+
+			continue
+		}
+
+		var removed, added []htmlBlock
+
+		for ; i < len(ops) && ops[i].kind != diffEqual; i++ {
+			if ops[i].kind == diffDelete {
+				removed = append(removed, oldBlocks[ops[i].a])
+			} else {
+				added = append(added, newBlocks[ops[i].b])
+			}
+		}
+
+		writeDiffHunk(&buf, removed, added)
+	}
+
+	buf.WriteString("</div>\n")
+
+	return buf.String(), nil
+}
+
+// writeDiffHunk pairs removed and added blocks of the same kind as changes,
+// the others are shown as they are.
+func writeDiffHunk(buf *strings.Builder, removed, added []htmlBlock) {
+	for i := range max(len(removed), len(added)) {
+		if i < len(removed) && i < len(added) {
+			if inline, ok := inlineDiff(removed[i], added[i]); ok {
+				buf.WriteString(`<div class="rich-diff-changed">` + "\n" + inline + "\n</div>\n")
+
+				continue
+			}
+		}
+
+		if i < len(removed) {
+			buf.WriteString(`<div class="rich-diff-removed">` + "\n" + removed[i].html + "\n</div>\n")
+		}
+
+		if i < len(added) {
+			buf.WriteString(`<div class="rich-diff-added">` + "\n" + added[i].html + "\n</div>\n")
+		}
+	}
+}
+
+// inlineDiff highlights the words changed between two versions of a block.
+// The markup of the new version is kept, removed words are inserted where
+// they were.
+func inlineDiff(old, updated htmlBlock) (string, bool) {
+	if old.tag != updated.tag || !slices.Contains(inlineDiffTags, updated.tag) {
+		return "", false
+	}
+
+	oldTokens := diffTokenRegexp.FindAllString(old.html, -1)
+	newTokens := diffTokenRegexp.FindAllString(updated.html, -1)
+
+	if len(oldTokens)*len(newTokens) > maxDiffCells {
+		return "", false
+	}
+
+	var buf strings.Builder
+
+	for _, op := range diffSlices(oldTokens, newTokens) {
+		switch op.kind {
+		case diffEqual:
+			buf.WriteString(newTokens[op.b])
+		case diffDelete:
+			// removed markup would leave unbalanced tags
+			if token := oldTokens[op.a]; !strings.HasPrefix(token, "<") {
+				buf.WriteString("<del>" + token + "</del>")
+			}
+		case diffInsert:
+			if token := newTokens[op.b]; strings.HasPrefix(token, "<") {
+				buf.WriteString(token)
+			} else {
+				buf.WriteString("<ins>" + token + "</ins>")
+			}
+		}
+	}
+
+	return mergeDiffMarks(buf.String()), true
+}
+
+func mergeDiffMarks(s string) string {
+	for _, re := range adjacentMarkRegexps {
+		s = re.ReplaceAllString(s, "$1")
+	}
+
+	return s
+}
+
+// htmlBlocks splits a document into its top level elements.
+func htmlBlocks(document string) ([]htmlBlock, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+
+	nodes, err := html.ParseFragment(strings.NewReader(document), context)
+	if err != nil {
+		return nil, fmt.Errorf("HTML parse error: %w", err)
+	}
+
+	blocks := make([]htmlBlock, 0, len(nodes))
+
+	for _, node := range nodes {
+		if node.Type == html.TextNode && strings.TrimSpace(node.Data) == "" {
+			continue
+		}
+
+		var buf strings.Builder
+
+		err := html.Render(&buf, node)
+		if err != nil {
+			return nil, fmt.Errorf("HTML render error: %w", err)
+		}
+
+		blocks = append(blocks, htmlBlock{tag: node.Data, html: buf.String()})
+	}
+
+	return blocks, nil
+}
+
+func blockHTML(blocks []htmlBlock) []string {
+	s := make([]string, len(blocks))
+	for i, block := range blocks {
+		s[i] = block.html
+	}
+
+	return s
+}
+
+// diffSlices returns the edit script turning a into b, based on their longest
+// common subsequence.
+func diffSlices(a, b []string) []diffOp {
+	// documents usually change in a few places, so the common start and end
+	// are skipped before building the quadratic table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, max(len(a), len(b)))
+	for i := range prefix {
+		ops = append(ops, diffOp{kind: diffEqual, a: i, b: i})
+	}
+
+	for _, op := range diffChanged(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		op.a += prefix
+		op.b += prefix
+		ops = append(ops, op)
+	}
+
+	for k := suffix; k > 0; k-- {
+		ops = append(ops, diffOp{kind: diffEqual, a: len(a) - k, b: len(b) - k})
+	}
+
+	return ops
+}
+
+// diffChanged returns the edit script of the changed part of two slices. When
+// comparing them would need more than maxDiffCells, everything is replaced.
+func diffChanged(a, b []string) []diffOp {
+	if len(a)*len(b) <= maxDiffCells {
+		return diffLCS(a, b)
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i := range a {
+		ops = append(ops, diffOp{kind: diffDelete, a: i})
+	}
+
+	for j := range b {
+		ops = append(ops, diffOp{kind: diffInsert, b: j})
+	}
+
+	return ops
+}
+
+func diffLCS(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, max(len(a), len(b)))
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{kind: diffEqual, a: i, b: j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: diffDelete, a: i})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: diffInsert, b: j})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{kind: diffDelete, a: i})
+	}
+
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{kind: diffInsert, b: j})
+	}
+
+	return ops
+}
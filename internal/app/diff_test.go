@@ -0,0 +1,68 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestRichDiff(t *testing.T) {
+	oldHTML := "<h1>Title</h1>\n<p>The quick brown fox.</p>\n<pre><code>old\n</code></pre>\n<p>Removed paragraph.</p>\n"
+	newHTML := "<h1>Title</h1>\n<p>The <em>slow</em> brown fox jumps.</p>\n<pre><code>new\n</code></pre>\n<blockquote>\n<p>Added quote.</p>\n</blockquote>\n"
+
+	diff, err := RichDiff(oldHTML, newHTML)
+	assert.Nil(t, err)
+
+	assert.True(t, strings.HasPrefix(diff, "<div class=\"rich-diff\">\n<h1>Title</h1>\n"))
+	assert.True(t, strings.Contains(diff, `<div class="rich-diff-changed">`+"\n<p>The <del>quick</del><em><ins>slow</ins></em> brown <del>fox.</del><ins>fox jumps.</ins></p>"))
+	assert.True(t, strings.Contains(diff, `<div class="rich-diff-removed">`+"\n<pre><code>old\n</code></pre>"))
+	assert.True(t, strings.Contains(diff, `<div class="rich-diff-added">`+"\n<pre><code>new\n</code></pre>"))
+	assert.True(t, strings.Contains(diff, `<div class="rich-diff-removed">`+"\n<p>Removed paragraph.</p>"))
+	assert.True(t, strings.Contains(diff, `<div class="rich-diff-added">`+"\n<blockquote>"))
+}
+
+func TestRichDiffUnchanged(t *testing.T) {
+	html := "<p>Same</p>\n<ul>\n<li>item</li>\n</ul>\n"
+
+	diff, err := RichDiff(html, html)
+	assert.Nil(t, err)
+	assert.Equal(t, diff, "<div class=\"rich-diff\">\n<p>Same</p>\n<ul>\n<li>item</li>\n</ul>\n</div>\n")
+}
+
+func TestDiffSlices(t *testing.T) {
+	ops := diffSlices([]string{"a", "b", "c", "d"}, []string{"a", "c", "x", "d"})
+
+	assert.DeepEqual(t, ops, []diffOp{
+		{kind: diffEqual, a: 0, b: 0},
+		{kind: diffDelete, a: 1, b: 1},
+		{kind: diffEqual, a: 2, b: 1},
+		{kind: diffInsert, a: 1, b: 2},
+		{kind: diffEqual, a: 3, b: 3},
+	})
+}
+
+func TestDiffSlicesLargeChange(t *testing.T) {
+	// the block kept is not found, the change is too large to compare
+	a := []string{"same"}
+	b := []string{}
+
+	for i := range 2100 {
+		a = append(a, fmt.Sprintf("<p>old %d</p>", i))
+		b = append(b, fmt.Sprintf("<p>new %d</p>", i))
+	}
+
+	b = append(b, "same")
+
+	ops := diffSlices(a, b)
+	assert.Equal(t, len(ops), len(a)+len(b))
+
+	for i, op := range ops {
+		if i < len(a) {
+			assert.DeepEqual(t, op, diffOp{kind: diffDelete, a: i})
+		} else {
+			assert.DeepEqual(t, op, diffOp{kind: diffInsert, b: i - len(a)})
+		}
+	}
+}
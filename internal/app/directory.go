@@ -117,24 +117,11 @@ func ListDirectoryContentsFS(fsys fs.FS, dir string, extensions []string) ([]str
 
 	var files, dirs []string
 
-	// Check if wildcard is enabled
-	allowAll := len(extensions) == 1 && extensions[0] == "*"
-
 	for _, entry := range entries {
 		if entry.IsDir() {
 			dirs = append(dirs, entry.Name())
-		} else {
-			// If wildcard, include all files
-			if allowAll {
-				files = append(files, entry.Name())
-
-				continue
-			}
-			// Check if file has one of the specified extensions (case-insensitive)
-			ext := strings.ToLower(filepath.Ext(entry.Name()))
-			if slices.Contains(extensions, ext) {
-				files = append(files, entry.Name())
-			}
+		} else if IsListedFile(entry.Name(), extensions) {
+			files = append(files, entry.Name())
 		}
 	}
 
@@ -145,6 +132,18 @@ func ListDirectoryContentsFS(fsys fs.FS, dir string, extensions []string) ([]str
 	return files, dirs, nil
 }
 
+// IsListedFile checks if a file is shown in directory listings, i.e. has one
+// of extensions (case-insensitive) or extensions is the '*' wildcard.
+func IsListedFile(filePath string, extensions []string) bool {
+	if len(extensions) == 1 && extensions[0] == "*" {
+		return true
+	}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+
+	return slices.Contains(extensions, ext)
+}
+
 // IsTextFile checks if a file is a text file based on allowed extensions (whitelist)
 // Returns true if the file extension is in the allowed list.
 func IsTextFile(filePath string, textExtensions []string) bool {
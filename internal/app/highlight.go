@@ -0,0 +1,59 @@
+package app
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/yuin/goldmark"
+	ast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// highlightLinesRegexp matches line ranges in fenced code info strings, e.g.
+// the "{3-5,8}" in "```go {3-5,8}".
+var highlightLinesRegexp = regexp.MustCompile(`\{\s*([0-9]+(?:-[0-9]+)?(?:\s*,\s*[0-9]+(?:-[0-9]+)?)*)\s*\}\s*$`)
+
+var highlightLinesAttrName = []byte("hl_lines")
+
+type highlightLinesExtender struct{}
+
+func newHighlightLinesExtender() *highlightLinesExtender {
+	return &highlightLinesExtender{}
+}
+
+func (e *highlightLinesExtender) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&highlightLinesTransformer{}, 999),
+	))
+}
+
+// highlightLinesTransformer converts line ranges in fenced code info strings
+// to the "hl_lines" attribute understood by goldmark-highlighting.
+type highlightLinesTransformer struct{}
+
+func (t *highlightLinesTransformer) Transform(doc *ast.Document, reader text.Reader, _ parser.Context) {
+	source := reader.Source()
+
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		n, ok := node.(*ast.FencedCodeBlock)
+		if !entering || !ok || n.Info == nil {
+			return ast.WalkContinue, nil
+		}
+
+		m := highlightLinesRegexp.FindSubmatch(n.Info.Segment.Value(source))
+		if m == nil {
+			return ast.WalkSkipChildren, nil
+		}
+
+		var lines []any
+		for r := range bytes.SplitSeq(m[1], []byte(",")) {
+			lines = append(lines, bytes.TrimSpace(r))
+		}
+
+		n.SetAttribute(highlightLinesAttrName, lines)
+
+		return ast.WalkSkipChildren, nil
+	})
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestHighlightLines(t *testing.T) {
+	markdown := "```go {2-3, 5}\npackage main\n\nimport \"fmt\"\n\nfunc main() {}\n```\n"
+
+	html, err := ToHTML(markdown, false)
+	assert.Nil(t, err)
+
+	assert.Equal(t, strings.Count(html, `<span class="line hl">`), 3)
+	assert.True(t, strings.Contains(html, `<span class="line hl"><span class="cl"><span class="kd">func</span>`))
+	assert.False(t, strings.Contains(html, `<span class="ln">`))
+}
+
+func TestHighlightLinesIgnoresOtherInfo(t *testing.T) {
+	html, err := ToHTML("```go {title=main.go}\npackage main\n```\n", false)
+	assert.Nil(t, err)
+
+	assert.False(t, strings.Contains(html, `hl`))
+	assert.True(t, strings.Contains(html, `<span class="kn">package</span>`))
+}
+
+func TestLineNumbers(t *testing.T) {
+	markdown := "```go {2}\npackage main\nfunc main() {}\n```\n"
+
+	html, err := ToHTML(markdown, false, WithLineNumbers())
+	assert.Nil(t, err)
+
+	assert.True(t, strings.Contains(html, `<span class="ln">1</span>`))
+	assert.True(t, strings.Contains(html, `<span class="line hl"><span class="ln">2</span>`))
+}
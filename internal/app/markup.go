@@ -0,0 +1,204 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/niklasfasching/go-org/org"
+)
+
+var (
+	ErrInvalidMarkupRenderer = errors.New("invalid markup renderer")
+	ErrNoMarkupRenderer      = errors.New("no markup renderer available")
+	errOrgInclude            = errors.New("org includes are not supported")
+)
+
+// Renderer converts a document written in a markup language to HTML.
+type Renderer interface {
+	Render(source string) (string, error)
+}
+
+// RendererFunc adapts a function to the Renderer interface.
+type RendererFunc func(source string) (string, error)
+
+func (f RendererFunc) Render(source string) (string, error) {
+	return f(source)
+}
+
+// MarkupRenderers selects the Renderer of a document by its file extension.
+type MarkupRenderers struct {
+	renderers map[string]Renderer
+}
+
+// NewMarkupRenderers returns the built-in renderers, overridden by specs in
+// the ".extension=command args..." format. Org documents are rendered in Go,
+// while reStructuredText and AsciiDoc need one of their usual commands in
+// PATH. Commands read the document from stdin and write HTML to stdout.
+func NewMarkupRenderers(specs []string, timeout time.Duration) (*MarkupRenderers, error) {
+	if timeout <= 0 {
+		timeout = DefaultCodeRendererTimeout
+	}
+
+	// Documents must not include other files from the machine, e.g. with
+	// the "include" directive, so the commands run in their safe modes
+	rst := newCommandRenderer(timeout,
+		[]string{"rst2html5", "--no-file-insertion"},
+		[]string{"rst2html5.py", "--no-file-insertion"},
+		[]string{"rst2html", "--no-file-insertion"},
+		[]string{"pandoc", "--sandbox", "--from=rst", "--to=html"},
+	)
+	asciidoc := newCommandRenderer(timeout,
+		[]string{"asciidoctor", "--safe-mode=secure", "--embedded", "--out-file=-", "-"},
+		[]string{"asciidoc", "--safe", "--no-header-footer", "--out-file=-", "-"},
+		[]string{"pandoc", "--sandbox", "--from=asciidoc", "--to=html"},
+	)
+
+	m := &MarkupRenderers{renderers: map[string]Renderer{}}
+	m.Register(".rst", rst)
+	m.Register(".rest", rst)
+	m.Register(".adoc", asciidoc)
+	m.Register(".asciidoc", asciidoc)
+	m.Register(".asc", asciidoc)
+	m.Register(".org", RendererFunc(orgToHTML))
+
+	for _, spec := range specs {
+		ext, command, ok := strings.Cut(spec, "=")
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		args := strings.Fields(command)
+
+		if !ok || !strings.HasPrefix(ext, ".") || len(args) == 0 {
+			return nil, fmt.Errorf("%w: %q, expected .extension=command", ErrInvalidMarkupRenderer, spec)
+		}
+
+		m.Register(ext, newCommandRenderer(timeout, args))
+	}
+
+	return m, nil
+}
+
+// Register sets r as the renderer of files with extension ext, e.g. ".rst".
+func (m *MarkupRenderers) Register(ext string, r Renderer) {
+	m.renderers[strings.ToLower(ext)] = r
+}
+
+// Get returns the renderer of filePath, if any.
+func (m *MarkupRenderers) Get(filePath string) (Renderer, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	r, ok := m.renderers[strings.ToLower(filepath.Ext(filePath))]
+
+	return r, ok
+}
+
+// commandRenderer renders documents with the first of its commands found in
+// PATH.
+type commandRenderer struct {
+	commands [][]string
+	timeout  time.Duration
+}
+
+func newCommandRenderer(timeout time.Duration, commands ...[]string) *commandRenderer {
+	return &commandRenderer{commands: commands, timeout: timeout}
+}
+
+func (c *commandRenderer) Render(source string) (string, error) {
+	for _, args := range c.commands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+
+		return c.run(args, source)
+	}
+
+	names := make([]string, 0, len(c.commands))
+	for _, args := range c.commands {
+		names = append(names, args[0])
+	}
+
+	return "", fmt.Errorf("%w: install one of %s", ErrNoMarkupRenderer, strings.Join(names, ", "))
+}
+
+func (c *commandRenderer) run(args []string, source string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // G204: commands are built-in or configured by the user
+	cmd.Stdin = strings.NewReader(source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = commandWaitDelay
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%w after %s: %s", errCodeRendererTimeout, c.timeout, args[0])
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return htmlBody(stdout.String()), nil
+}
+
+// htmlBody returns the contents of the <body> element for commands that
+// output a complete HTML document.
+func htmlBody(document string) string {
+	start := strings.Index(document, "<body")
+	if start < 0 {
+		return document
+	}
+
+	open := strings.Index(document[start:], ">")
+	end := strings.LastIndex(document, "</body>")
+
+	if open < 0 || end < start+open {
+		return document
+	}
+
+	return strings.TrimSpace(document[start+open+1 : end])
+}
+
+func orgToHTML(source string) (string, error) {
+	conf := org.New()
+	conf.Log = log.New(io.Discard, "", 0)
+	conf.DefaultSettings["OPTIONS"] = strings.Replace(conf.DefaultSettings["OPTIONS"], "toc:t", "toc:nil", 1)
+	// Includes could read files outside of the previewed directory
+	conf.ReadFile = func(string) ([]byte, error) {
+		return nil, errOrgInclude
+	}
+
+	writer := org.NewHTMLWriter()
+	writer.TopLevelHLevel = 1
+	writer.HighlightCodeBlock = func(source, lang string, inline bool, _ map[string]string) string {
+		if inline {
+			return "<code>" + html.EscapeString(source) + "</code>"
+		}
+
+		code, err := highlightCode(source, lexerByName(lang))
+		if err != nil {
+			return "<pre><code>" + html.EscapeString(source) + "</code></pre>"
+		}
+
+		return code
+	}
+
+	out, err := conf.Parse(strings.NewReader(source), "").Write(writer)
+	if err != nil {
+		return "", fmt.Errorf("org convert error: %w", err)
+	}
+
+	return out, nil
+}
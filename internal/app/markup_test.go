@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestNewMarkupRenderers(t *testing.T) {
+	renderers, err := NewMarkupRenderers([]string{".RST=cat", " .textile = cat - "}, time.Second)
+	assert.Nil(t, err)
+
+	for _, file := range []string{"README.rst", "docs/index.adoc", "notes.org", "page.textile"} {
+		_, ok := renderers.Get(file)
+		assert.True(t, ok)
+	}
+
+	_, ok := renderers.Get("README.md")
+	assert.False(t, ok)
+
+	renderer, _ := renderers.Get("README.rst")
+	html, err := renderer.Render("<html><body class=\"x\">\n<p>Hi</p>\n</body></html>")
+	assert.Nil(t, err)
+	assert.Equal(t, html, "<p>Hi</p>")
+
+	for _, spec := range []string{"rst", "rst=cat", ".rst=", "=cat"} {
+		_, err := NewMarkupRenderers([]string{spec}, 0)
+		assert.True(t, errors.Is(err, ErrInvalidMarkupRenderer))
+	}
+}
+
+func TestMarkupRenderersSafeMode(t *testing.T) {
+	renderers, err := NewMarkupRenderers(nil, time.Second)
+	assert.Nil(t, err)
+
+	safeFlags := []string{"--no-file-insertion", "--sandbox", "--safe-mode=secure", "--safe"}
+
+	for _, file := range []string{"README.rst", "README.adoc"} {
+		renderer, _ := renderers.Get(file)
+		command, ok := renderer.(*commandRenderer)
+		assert.True(t, ok)
+
+		for _, args := range command.commands {
+			assert.True(t, slices.ContainsFunc(args, func(arg string) bool { return slices.Contains(safeFlags, arg) }))
+		}
+	}
+}
+
+func TestCommandRendererNotInstalled(t *testing.T) {
+	renderer := newCommandRenderer(time.Second, []string{"gh-gfm-preview-missing-command"})
+
+	_, err := renderer.Render("text")
+	assert.True(t, errors.Is(err, ErrNoMarkupRenderer))
+}
+
+func TestOrgToHTML(t *testing.T) {
+	html, err := orgToHTML("* Heading\nSome /text/.\n#+BEGIN_SRC go\nfunc main() {}\n#+END_SRC\n")
+	assert.Nil(t, err)
+
+	assert.True(t, strings.Contains(html, `<h1 id="headline-1">`))
+	assert.True(t, strings.Contains(html, "<em>text</em>"))
+	assert.True(t, strings.Contains(html, `<span class="kd">func</span>`))
+	assert.False(t, strings.Contains(html, "table-of-contents"))
+}
+
+func TestFindReadmePrefersRenderableFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README":      {},
+		"readme.go":   {},
+		"README.txt":  {},
+		"README.rst":  {},
+		"README.html": {},
+	}
+
+	readme, err := FindReadmeFS(fsys, ".")
+	assert.Nil(t, err)
+	assert.Equal(t, readme, "README.rst")
+
+	fsys["readme.md"] = &fstest.MapFile{}
+
+	readme, err = FindReadmeFS(fsys, ".")
+	assert.Nil(t, err)
+	assert.Equal(t, readme, "readme.md")
+
+	readme, err = FindReadmeFS(fstest.MapFS{"readme.go": {}, "README.html": {}}, ".")
+	assert.Nil(t, err)
+	assert.Equal(t, readme, "README.html")
+}
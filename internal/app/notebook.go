@@ -0,0 +1,241 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+const defaultNotebookLanguage = "python"
+
+// ansiEscapeRegexp matches the terminal color codes found in tracebacks and
+// stream outputs.
+var ansiEscapeRegexp = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// notebookImageTypes are the image MIME types displayed from outputs, in
+// order of preference.
+var notebookImageTypes = []string{"image/svg+xml", "image/png", "image/jpeg", "image/gif"}
+
+// notebookText is a multiline string, stored in notebooks either as a string
+// or as a list of lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(b []byte) error {
+	var lines []string
+
+	err := json.Unmarshal(b, &lines)
+	if err == nil {
+		*t = notebookText(strings.Join(lines, ""))
+
+		return nil
+	}
+
+	var s string
+
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return fmt.Errorf("notebook text error: %w", err)
+	}
+
+	*t = notebookText(s)
+
+	return nil
+}
+
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType       string           `json:"cell_type"`
+	Source         notebookText     `json:"source"`
+	ExecutionCount *int             `json:"execution_count"`
+	Outputs        []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType     string                     `json:"output_type"`
+	Name           string                     `json:"name"`
+	Text           notebookText               `json:"text"`
+	Data           map[string]json.RawMessage `json:"data"`
+	ExecutionCount *int                       `json:"execution_count"`
+	Ename          string                     `json:"ename"`
+	Evalue         string                     `json:"evalue"`
+	Traceback      []string                   `json:"traceback"`
+}
+
+// IsNotebookFile checks if filePath is a Jupyter notebook.
+func IsNotebookFile(filePath string) bool {
+	return strings.ToLower(filepath.Ext(filePath)) == ".ipynb"
+}
+
+// NotebookToHTML renders a Jupyter notebook like GitHub does: markdown cells
+// are converted with ToHTML, code cells are highlighted in the notebook
+// language and stored outputs are shown as is. Nothing is executed.
+func NotebookToHTML(source string, isMarkdownMode bool, opts ...Option) (string, error) {
+	var nb notebook
+
+	err := json.Unmarshal([]byte(source), &nb)
+	if err != nil {
+		return "", fmt.Errorf("notebook parse error: %w", err)
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	language := nb.Metadata.LanguageInfo.Name
+	if language == "" {
+		language = nb.Metadata.Kernelspec.Language
+	}
+
+	if language == "" {
+		language = defaultNotebookLanguage
+	}
+
+	var buf strings.Builder
+
+	buf.WriteString(`<div class="notebook">` + "\n")
+
+	for _, cell := range nb.Cells {
+		switch cell.CellType {
+		case "markdown":
+			markdown, err := ToHTML(string(cell.Source), isMarkdownMode, opts...)
+			if err != nil {
+				return "", err
+			}
+
+			buf.WriteString(`<div class="notebook-cell notebook-markdown-cell">` + "\n")
+			buf.WriteString(markdown)
+			buf.WriteString("</div>\n")
+		case "code":
+			code, err := highlightCode(string(cell.Source), lexerByName(language), chromahtml.WithLineNumbers(o.lineNumbers))
+			if err != nil {
+				return "", err
+			}
+
+			buf.WriteString(`<div class="notebook-cell notebook-code-cell">` + "\n")
+			writeNotebookPrompt(&buf, "In", cell.ExecutionCount)
+			buf.WriteString(`<div class="notebook-input">` + code + "</div>\n</div>\n")
+
+			for _, output := range cell.Outputs {
+				writeNotebookOutput(&buf, output)
+			}
+		default:
+			buf.WriteString(`<div class="notebook-cell notebook-raw-cell">` + "\n")
+			buf.WriteString("<pre><code>" + html.EscapeString(string(cell.Source)) + "</code></pre>\n</div>\n")
+		}
+	}
+
+	buf.WriteString("</div>\n")
+
+	return buf.String(), nil
+}
+
+func writeNotebookPrompt(buf *strings.Builder, label string, count *int) {
+	prompt := " "
+	if count != nil {
+		prompt = fmt.Sprint(*count)
+	}
+
+	fmt.Fprintf(buf, `<div class="notebook-prompt">%s [%s]:</div>`+"\n", label, prompt)
+}
+
+func writeNotebookOutput(buf *strings.Builder, output notebookOutput) {
+	buf.WriteString(`<div class="notebook-cell notebook-output">` + "\n")
+
+	if output.OutputType == "execute_result" {
+		writeNotebookPrompt(buf, "Out", output.ExecutionCount)
+	}
+
+	buf.WriteString(`<div class="notebook-output-content">`)
+
+	switch output.OutputType {
+	case "stream":
+		fmt.Fprintf(buf, `<pre class="notebook-stream notebook-%s">%s</pre>`,
+			html.EscapeString(output.Name), escapeTerminalText(string(output.Text)))
+	case "error":
+		text := strings.Join(output.Traceback, "\n")
+		if text == "" {
+			text = output.Ename + ": " + output.Evalue
+		}
+
+		fmt.Fprintf(buf, `<pre class="notebook-error">%s</pre>`, escapeTerminalText(text))
+	default:
+		writeNotebookData(buf, output.Data)
+	}
+
+	buf.WriteString("</div>\n</div>\n")
+}
+
+// writeNotebookData writes the richest representation of display data.
+// Images are embedded as data URLs, so SVG scripts don't run.
+func writeNotebookData(buf *strings.Builder, data map[string]json.RawMessage) {
+	if s, ok := notebookData(data, "text/html"); ok {
+		buf.WriteString(s)
+
+		return
+	}
+
+	for _, mimeType := range notebookImageTypes {
+		s, ok := notebookData(data, mimeType)
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(buf, `<img src="%s" alt="output">`, html.EscapeString(notebookImageURL(mimeType, s)))
+
+		return
+	}
+
+	if s, ok := notebookData(data, "text/plain"); ok {
+		buf.WriteString("<pre>" + escapeTerminalText(s) + "</pre>")
+	}
+}
+
+// notebookData returns the textual data of mimeType. Other MIME types, e.g.
+// JSON used by widgets, may hold arbitrary values and are only decoded on
+// demand.
+func notebookData(data map[string]json.RawMessage, mimeType string) (string, bool) {
+	raw, ok := data[mimeType]
+	if !ok {
+		return "", false
+	}
+
+	var text notebookText
+
+	err := json.Unmarshal(raw, &text)
+	if err != nil {
+		return "", false
+	}
+
+	return string(text), true
+}
+
+func notebookImageURL(mimeType, data string) string {
+	if mimeType == "image/svg+xml" {
+		return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(data))
+	}
+
+	// Base64 encoded images may be split in multiple lines
+	return "data:" + mimeType + ";base64," + strings.Join(strings.Fields(data), "")
+}
+
+func escapeTerminalText(text string) string {
+	return html.EscapeString(ansiEscapeRegexp.ReplaceAllString(text, ""))
+}
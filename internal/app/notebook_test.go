@@ -0,0 +1,77 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+const testNotebook = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Title\n", "\n", "Some *text*"]},
+  {
+   "cell_type": "code",
+   "execution_count": 2,
+   "metadata": {},
+   "source": "print(\"hello\")\n1 + 1",
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["hello\n"]},
+    {"output_type": "execute_result", "execution_count": 2, "metadata": {}, "data": {"text/plain": ["2"]}},
+    {"output_type": "display_data", "metadata": {}, "data": {"image/png": "iVBORw0KGgo=\n", "text/plain": "<Figure>"}},
+    {"output_type": "display_data", "metadata": {}, "data": {"application/vnd.jupyter.widget-view+json": {"model_id": "1"}, "text/html": "<b>widget</b>"}},
+    {"output_type": "error", "ename": "ValueError", "evalue": "bad", "traceback": ["\u001b[0;31mValueError\u001b[0m: <bad>"]}
+   ]
+  },
+  {"cell_type": "code", "execution_count": null, "metadata": {}, "source": "", "outputs": []},
+  {"cell_type": "raw", "metadata": {}, "source": "<raw>"}
+ ],
+ "metadata": {"language_info": {"name": "python"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}`
+
+func TestNotebookToHTML(t *testing.T) {
+	html, err := NotebookToHTML(testNotebook, false)
+	assert.Nil(t, err)
+
+	for _, expected := range []string{
+		`<h1 id="title">`,
+		"<em>text</em>",
+		`<div class="notebook-prompt">In [2]:</div>`,
+		`<span class="nb">print</span>`,
+		`<pre class="notebook-stream notebook-stdout">hello` + "\n</pre>",
+		`<div class="notebook-prompt">Out [2]:</div>`,
+		"<pre>2</pre>",
+		`<img src="data:image/png;base64,iVBORw0KGgo=" alt="output">`,
+		"<b>widget</b>",
+		`<pre class="notebook-error">ValueError: &lt;bad&gt;</pre>`,
+		`<div class="notebook-prompt">In [ ]:</div>`,
+		"<pre><code>&lt;raw&gt;</code></pre>",
+	} {
+		assert.True(t, strings.Contains(html, expected))
+	}
+
+	assert.False(t, strings.Contains(html, "&lt;Figure&gt;"))
+}
+
+func TestNotebookToHTMLSVGOutput(t *testing.T) {
+	html, err := NotebookToHTML(`{"cells": [{"cell_type": "code", "source": "", "outputs": [
+		{"output_type": "display_data", "data": {"image/svg+xml": ["<svg>", "<script></script>", "</svg>"]}}
+	]}]}`, false)
+	assert.Nil(t, err)
+
+	assert.True(t, strings.Contains(html, `<img src="data:image/svg+xml;base64,`))
+	assert.False(t, strings.Contains(html, "<script>"))
+}
+
+func TestNotebookToHTMLInvalid(t *testing.T) {
+	_, err := NotebookToHTML("not a notebook", false)
+	assert.NotNil(t, err)
+}
+
+func TestIsNotebookFile(t *testing.T) {
+	assert.True(t, IsNotebookFile("analysis.ipynb"))
+	assert.True(t, IsNotebookFile("dir/Analysis.IPYNB"))
+	assert.False(t, IsNotebookFile("analysis.py"))
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// maxHighlightSize is the size above which source files are shown without
+// syntax highlighting, since tokenizing them would be too slow.
+const maxHighlightSize = 1 << 20
+
+var markdownExtensions = []string{".md", ".markdown", ".mdown", ".mdwn", ".mkd", ".mkdn"}
+
+// IsMarkdownFile checks if filePath is a Markdown file, either by its
+// extension or because it is a README without extension.
+func IsMarkdownFile(filePath string) bool {
+	base := path.Base(filePath)
+	ext := strings.ToLower(path.Ext(base))
+
+	if ext == "" {
+		return readmePattern.MatchString(base)
+	}
+
+	return slices.Contains(markdownExtensions, ext)
+}
+
+// SourceToHTML renders a source file like GitHub's blob view, highlighted
+// with the lexer detected from filename and with linkable line numbers.
+func SourceToHTML(filename, source string) (string, error) {
+	lexer := lexers.Fallback
+	if len(source) <= maxHighlightSize {
+		lexer = sourceLexer(filename, source)
+	}
+
+	code, err := highlightCode(
+		source,
+		lexer,
+		chromahtml.WithLineNumbers(true),
+		chromahtml.WithLinkableLineNumbers(true, "L"),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Count(source, "\n")
+	if source != "" && !strings.HasSuffix(source, "\n") {
+		lines++
+	}
+
+	language := lexer.Config().Name
+	if lexer == lexers.Fallback {
+		language = "Plain text"
+	}
+
+	unit := "lines"
+	if lines == 1 {
+		unit = "line"
+	}
+
+	var buf strings.Builder
+
+	buf.WriteString(`<div class="source-file">` + "\n")
+	fmt.Fprintf(&buf, `<div class="source-file-info">%d %s · %s</div>`+"\n", lines, unit, html.EscapeString(language))
+	buf.WriteString(code)
+	buf.WriteString("</div>\n")
+
+	return buf.String(), nil
+}
+
+func sourceLexer(filename, source string) chroma.Lexer {
+	lexer := lexers.Match(path.Base(filename))
+	if lexer == nil {
+		lexer = lexers.Analyse(source)
+	}
+
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	return lexer
+}
+
+func lexerByName(name string) chroma.Lexer {
+	lexer := lexers.Get(name)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	return lexer
+}
+
+// highlightCode formats code with CSS classes, so the configured chroma
+// styles apply.
+func highlightCode(code string, lexer chroma.Lexer, opts ...chromahtml.Option) (string, error) {
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return "", fmt.Errorf("highlight error: %w", err)
+	}
+
+	formatter := chromahtml.New(append([]chromahtml.Option{chromahtml.WithClasses(true)}, opts...)...)
+
+	var buf bytes.Buffer
+
+	err = formatter.Format(&buf, styles.Fallback, iterator)
+	if err != nil {
+		return "", fmt.Errorf("highlight error: %w", err)
+	}
+
+	return buf.String(), nil
+}
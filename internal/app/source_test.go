@@ -0,0 +1,34 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestIsMarkdownFile(t *testing.T) {
+	assert.True(t, IsMarkdownFile("README.md"))
+	assert.True(t, IsMarkdownFile("docs/guide.Markdown"))
+	assert.True(t, IsMarkdownFile("README"))
+	assert.False(t, IsMarkdownFile("main.go"))
+	assert.False(t, IsMarkdownFile("notes.txt"))
+	assert.False(t, IsMarkdownFile("Makefile"))
+}
+
+func TestSourceToHTML(t *testing.T) {
+	html, err := SourceToHTML("cmd/main.go", "package main\n\nfunc main() {}\n")
+	assert.Nil(t, err)
+
+	assert.True(t, strings.Contains(html, `<div class="source-file-info">3 lines · Go</div>`))
+	assert.True(t, strings.Contains(html, `<span class="ln" id="L3"><a class="lnlinks" href="#L3">3</a></span>`))
+	assert.True(t, strings.Contains(html, `<span class="kd">func</span>`))
+}
+
+func TestSourceToHTMLUnknownFile(t *testing.T) {
+	html, err := SourceToHTML("notes.unknown", "a < b & c")
+	assert.Nil(t, err)
+
+	assert.True(t, strings.Contains(html, "1 line · Plain text"))
+	assert.True(t, strings.Contains(html, "a &lt; b &amp; c"))
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MaxTableRows is the maximum number of data rows rendered by TableToHTML,
+// larger files are truncated with a notice.
+const MaxTableRows = 1000
+
+var tableDelimiters = map[string]rune{
+	".csv": ',',
+	".tsv": '\t',
+}
+
+// TableDelimiter returns the field delimiter for filePath if it is a CSV or
+// TSV file.
+func TableDelimiter(filePath string) (rune, bool) {
+	delimiter, ok := tableDelimiters[strings.ToLower(filepath.Ext(filePath))]
+
+	return delimiter, ok
+}
+
+// IsTableFile checks if filePath is a CSV or TSV file rendered as a table.
+func IsTableFile(filePath string) bool {
+	_, ok := TableDelimiter(filePath)
+
+	return ok
+}
+
+// TableToHTML renders delimiter-separated source as a table like GitHub does
+// for CSV and TSV files. The first row is used as the header when it looks
+// like one. Files that can't be parsed are shown as plain text below a banner
+// pointing at the offending line.
+func TableToHTML(source string, delimiter rune) string {
+	reader := csv.NewReader(strings.NewReader(source))
+	reader.Comma = delimiter
+
+	var (
+		records [][]string
+		total   int
+	)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			message, line := tableParseError(err)
+			if errors.Is(err, csv.ErrFieldCount) {
+				message = fmt.Sprintf("It looks like row %d should actually have %d columns, instead of %d",
+					total+1, len(records[0]), len(record))
+			}
+
+			return tableErrorHTML(source, message, line)
+		}
+
+		total++
+
+		// Keep one extra row so the header doesn't count against the limit.
+		if len(records) <= MaxTableRows {
+			records = append(records, record)
+		}
+	}
+
+	return tableHTML(records, total)
+}
+
+func tableHTML(records [][]string, total int) string {
+	var buf strings.Builder
+
+	buf.WriteString(`<div class="table-file">` + "\n")
+
+	if len(records) == 0 {
+		buf.WriteString(`<p class="table-file-notice">This file is empty.</p>` + "\n</div>\n")
+
+		return buf.String()
+	}
+
+	buf.WriteString(`<table class="table-file-data">` + "\n")
+
+	rows := records
+	if hasTableHeader(records) {
+		buf.WriteString("<thead>\n<tr>\n<th></th>\n")
+
+		for _, cell := range records[0] {
+			fmt.Fprintf(&buf, "<th>%s</th>\n", html.EscapeString(cell))
+		}
+
+		buf.WriteString("</tr>\n</thead>\n")
+
+		rows = records[1:]
+		total--
+	}
+
+	rows = rows[:min(len(rows), MaxTableRows)]
+
+	buf.WriteString("<tbody>\n")
+
+	for i, row := range rows {
+		fmt.Fprintf(&buf, "<tr>\n<td class=\"table-file-row-number\">%d</td>\n", i+1)
+
+		for _, cell := range row {
+			fmt.Fprintf(&buf, "<td>%s</td>\n", html.EscapeString(cell))
+		}
+
+		buf.WriteString("</tr>\n")
+	}
+
+	buf.WriteString("</tbody>\n</table>\n")
+
+	if total > len(rows) {
+		fmt.Fprintf(&buf, "<p class=\"table-file-notice\">Showing the first %d of %d rows.</p>\n", len(rows), total)
+	}
+
+	buf.WriteString("</div>\n")
+
+	return buf.String()
+}
+
+// hasTableHeader reports whether the first record looks like a header: there
+// are more rows after it and none of its cells are empty or numeric.
+func hasTableHeader(records [][]string) bool {
+	if len(records) < 2 {
+		return false
+	}
+
+	for _, cell := range records[0] {
+		cell = strings.TrimSpace(cell)
+		if cell == "" {
+			return false
+		}
+
+		if _, err := strconv.ParseFloat(cell, 64); err == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tableParseError returns a human readable message and the line of a CSV
+// parse error, or 0 if the line is unknown.
+func tableParseError(err error) (string, int) {
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) {
+		return err.Error(), 0
+	}
+
+	message := parseErr.Err.Error()
+
+	return strings.ToUpper(message[:1]) + message[1:], parseErr.Line
+}
+
+func tableErrorHTML(source, message string, line int) string {
+	var buf strings.Builder
+
+	buf.WriteString(`<div class="markdown-alert markdown-alert-warning table-file-error">` + "\n")
+	buf.WriteString(`<p>We can make this file <strong>beautiful and searchable</strong> if this error is corrected: `)
+	buf.WriteString(html.EscapeString(message))
+
+	if line > 0 {
+		fmt.Fprintf(&buf, ` in <a href="#L%d">line %d</a>`, line, line)
+	}
+
+	buf.WriteString(".</p>\n</div>\n")
+	buf.WriteString(`<pre class="table-file-source"><code>`)
+
+	for i, text := range strings.Split(strings.TrimSuffix(source, "\n"), "\n") {
+		class := "table-file-line"
+		if i+1 == line {
+			class += " table-file-error-line"
+		}
+
+		fmt.Fprintf(&buf, `<span id="L%d" class="%s">%s</span>`+"\n", i+1, class, html.EscapeString(text))
+	}
+
+	buf.WriteString("</code></pre>\n")
+
+	return buf.String()
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestTableDelimiter(t *testing.T) {
+	delimiter, ok := TableDelimiter("data.csv")
+	assert.True(t, ok)
+	assert.Equal(t, delimiter, ',')
+
+	delimiter, ok = TableDelimiter("dir/DATA.TSV")
+	assert.True(t, ok)
+	assert.Equal(t, delimiter, '\t')
+
+	assert.False(t, IsTableFile("README.md"))
+}
+
+func TestTableToHTML(t *testing.T) {
+	html := TableToHTML("name,description\nfoo,\"a, <b>\"\n", ',')
+
+	assert.True(t, strings.Contains(html, "<thead>\n<tr>\n<th></th>\n<th>name</th>\n<th>description</th>"))
+	assert.True(t, strings.Contains(html, `<td class="table-file-row-number">1</td>`))
+	assert.True(t, strings.Contains(html, "<td>a, &lt;b&gt;</td>"))
+	assert.False(t, strings.Contains(html, "table-file-notice"))
+}
+
+func TestTableToHTMLWithoutHeader(t *testing.T) {
+	for _, source := range []string{"1,2\n3,4\n", "name,\nfoo,bar\n", "name,value\n"} {
+		html := TableToHTML(source, ',')
+
+		assert.False(t, strings.Contains(html, "<thead>"))
+		assert.True(t, strings.Contains(html, "<tbody>"))
+	}
+}
+
+func TestTableToHTMLRowLimit(t *testing.T) {
+	var source strings.Builder
+
+	source.WriteString("id\n")
+
+	for i := range MaxTableRows + 5 {
+		fmt.Fprintf(&source, "row%d\n", i)
+	}
+
+	html := TableToHTML(source.String(), ',')
+
+	assert.Equal(t, strings.Count(html, `class="table-file-row-number"`), MaxTableRows)
+	assert.True(t, strings.Contains(html, fmt.Sprintf("Showing the first %d of %d rows.", MaxTableRows, MaxTableRows+5)))
+}
+
+func TestTableToHTMLParseError(t *testing.T) {
+	html := TableToHTML("a,b\n1,2\n3,4,5\n", ',')
+
+	assert.True(t, strings.Contains(html, "It looks like row 3 should actually have 2 columns, instead of 3"))
+	assert.True(t, strings.Contains(html, `<a href="#L3">line 3</a>`))
+	assert.True(t, strings.Contains(html, `<span id="L3" class="table-file-line table-file-error-line">3,4,5</span>`))
+	assert.False(t, strings.Contains(html, "<table"))
+
+	html = TableToHTML("a,\"b\n", ',')
+
+	assert.True(t, strings.Contains(html, "Extraneous or missing"))
+}
+
+func TestTableToHTMLEmpty(t *testing.T) {
+	assert.True(t, strings.Contains(TableToHTML("", ','), "This file is empty."))
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/yuin/goldmark"
+	ast "github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// ErrTaskNotFound is returned when there is no task list item at a line.
+var ErrTaskNotFound = errors.New("task list item not found")
+
+// taskLineRegexp matches a task list item line, including items nested in
+// block quotes, capturing the checkbox state.
+var taskLineRegexp = regexp.MustCompile(`^[ \t>]*(?:[-+*]|[0-9]{1,9}[.)])[ \t]+\[([ xX])\]`)
+
+type interactiveTaskExtender struct{}
+
+func newInteractiveTaskExtender() *interactiveTaskExtender {
+	return &interactiveTaskExtender{}
+}
+
+func (e *interactiveTaskExtender) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&interactiveTaskHTMLRenderer{}, 400),
+	))
+}
+
+type interactiveTaskHTMLRenderer struct{}
+
+func (r *interactiveTaskHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(extast.KindTaskCheckBox, r.renderTaskCheckBox)
+}
+
+// renderTaskCheckBox renders an enabled checkbox annotated with the source
+// line of its list item, so the client can ask for it to be toggled.
+func (r *interactiveTaskHTMLRenderer) renderTaskCheckBox(
+	w util.BufWriter, source []byte, node ast.Node, entering bool,
+) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n, ok := node.(*extast.TaskCheckBox)
+	if !ok {
+		return ast.WalkStop, nil
+	}
+
+	_, _ = w.WriteString(`<input`)
+	if n.IsChecked {
+		_, _ = w.WriteString(` checked=""`)
+	}
+
+	_, _ = w.WriteString(` type="checkbox" class="task-list-item-checkbox"`)
+
+	if parent := n.Parent(); parent != nil && parent.Lines().Len() > 0 {
+		line := bytes.Count(source[:parent.Lines().At(0).Start], []byte("\n")) + 1
+
+		_, _ = w.WriteString(` data-task-line="`)
+		_, _ = w.WriteString(strconv.Itoa(line))
+		_, _ = w.WriteString(`"`)
+	}
+
+	_, _ = w.WriteString("> ")
+
+	return ast.WalkContinue, nil
+}
+
+// ToggleTask sets the checkbox of the task list item at line (1-based) of
+// source to checked, preserving everything else including line endings.
+func ToggleTask(source []byte, line int, checked bool) ([]byte, error) {
+	start := 0
+
+	for i := 1; i < line; i++ {
+		next := bytes.IndexByte(source[start:], '\n')
+		if next < 0 {
+			return nil, fmt.Errorf("%w: line %d", ErrTaskNotFound, line)
+		}
+
+		start += next + 1
+	}
+
+	end := len(source)
+	if next := bytes.IndexByte(source[start:], '\n'); next >= 0 {
+		end = start + next
+	}
+
+	m := taskLineRegexp.FindSubmatchIndex(source[start:end])
+	if line < 1 || m == nil {
+		return nil, fmt.Errorf("%w: line %d", ErrTaskNotFound, line)
+	}
+
+	state := byte(' ')
+	if checked {
+		state = 'x'
+	}
+
+	result := bytes.Clone(source)
+	result[start+m[2]] = state
+
+	return result, nil
+}
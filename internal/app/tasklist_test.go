@@ -0,0 +1,57 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestInteractiveTasks(t *testing.T) {
+	markdown := "# Release\n\n- [ ] Tag\n- [x] Build\n  1. [ ] Nested\n\n> - [X] Quoted\n"
+
+	html, err := ToHTML(markdown, false, WithInteractiveTasks())
+	assert.Nil(t, err)
+
+	assert.False(t, strings.Contains(html, "disabled"))
+	assert.True(t, strings.Contains(html, `<input type="checkbox" class="task-list-item-checkbox" data-task-line="3"> Tag`))
+	assert.True(t, strings.Contains(html, `<input checked="" type="checkbox" class="task-list-item-checkbox" data-task-line="4"> Build`))
+	assert.True(t, strings.Contains(html, `data-task-line="5"> Nested`))
+	assert.True(t, strings.Contains(html, `<input checked="" type="checkbox" class="task-list-item-checkbox" data-task-line="7"> Quoted`))
+
+	html, err = ToHTML(markdown, false)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(html, `<input disabled="" type="checkbox"> Tag`))
+}
+
+func TestToggleTask(t *testing.T) {
+	source := []byte("# Release\r\n\r\n- [ ] Tag\r\n- [x] Build\r\n  1. [ ] Nested\r\n> * [X] Quoted")
+
+	tests := []struct {
+		name    string
+		line    int
+		checked bool
+		want    string
+	}{
+		{"Check", 3, true, "- [x] Tag\r\n"},
+		{"Uncheck", 4, false, "- [ ] Build\r\n"},
+		{"Nested ordered item", 5, true, "  1. [x] Nested\r\n"},
+		{"Quoted last line", 6, false, "> * [ ] Quoted"},
+		{"Already checked", 4, true, "- [x] Build\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ToggleTask(source, tt.line, tt.checked)
+			assert.Nil(t, err)
+			assert.True(t, strings.Contains(string(result), tt.want))
+			assert.Equal(t, len(result), len(source))
+		})
+	}
+
+	for _, line := range []int{0, 1, 2, 7} {
+		_, err := ToggleTask(source, line, true)
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+	}
+}
@@ -0,0 +1,271 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	ast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// wikiExtensions are the page formats GitHub wikis can be written in.
+var wikiExtensions = slices.Concat(
+	markdownExtensions,
+	[]string{".rst", ".rest", ".adoc", ".asciidoc", ".asc", ".org", ".textile", ".mediawiki", ".wiki", ".creole", ".rdoc", ".pod"},
+)
+
+// wikiImageExtensions are the targets of wiki links shown as images.
+var wikiImageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".bmp", ".ico"}
+
+// WikiPage is a page of a GitHub wiki, Name is its title as shown by GitHub
+// and Path its file.
+type WikiPage struct {
+	Name string
+	Path string
+}
+
+// WikiPages indexes the pages of a GitHub wiki by name. Like GitHub, page
+// names are case insensitive and spaces and dashes are interchangeable.
+type WikiPages struct {
+	pages  []WikiPage
+	byName map[string]string
+	// byPath is like byName, with the directory of the page in the key
+	byPath map[string]string
+}
+
+// NewWikiPages finds the pages of the wiki checked out in fsys, skipping
+// hidden directories such as .git.
+func NewWikiPages(fsys fs.FS) (*WikiPages, error) {
+	w := &WikiPages{byName: map[string]string{}, byPath: map[string]string{}}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if name != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if !IsWikiPage(name) {
+			return nil
+		}
+
+		w.pages = append(w.pages, WikiPage{Name: WikiPageName(name), Path: name})
+
+		// GitHub doesn't allow two pages with the same name, links go to the
+		// first one
+		key := wikiPageKey(strings.TrimSuffix(d.Name(), path.Ext(name)))
+		if _, ok := w.byName[key]; !ok {
+			w.byName[key] = name
+		}
+
+		pathKey := path.Join(path.Dir(name), wikiPageKey(WikiPageName(name)))
+		if _, ok := w.byPath[pathKey]; !ok {
+			w.byPath[pathKey] = name
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("wiki walk error: %w", err)
+	}
+
+	slices.SortFunc(w.pages, func(a, b WikiPage) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return w, nil
+}
+
+// IsWikiPage checks if filePath has the extension of a wiki page.
+func IsWikiPage(filePath string) bool {
+	return slices.Contains(wikiExtensions, strings.ToLower(path.Ext(filePath)))
+}
+
+// WikiPageName returns the title of the wiki page in filePath, e.g. "Getting
+// Started" for Getting-Started.md.
+func WikiPageName(filePath string) string {
+	base := path.Base(filePath)
+
+	return strings.ReplaceAll(strings.TrimSuffix(base, path.Ext(base)), "-", " ")
+}
+
+func wikiPageKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "-"))
+}
+
+// Resolve returns the file of the page called name.
+func (w *WikiPages) Resolve(name string) (string, bool) {
+	file, ok := w.byName[wikiPageKey(name)]
+
+	return file, ok
+}
+
+// Pages returns the pages of the wiki sorted by name, without the special
+// pages such as _Sidebar and _Footer.
+func (w *WikiPages) Pages() []WikiPage {
+	pages := make([]WikiPage, 0, len(w.pages))
+
+	for _, page := range w.pages {
+		if !IsWikiSpecialPage(page.Path) {
+			pages = append(pages, page)
+		}
+	}
+
+	return pages
+}
+
+// Nearest returns the special page name (e.g. "_Sidebar") of dir, or of its
+// closest parent directory having one.
+func (w *WikiPages) Nearest(dir, name string) (string, bool) {
+	key := wikiPageKey(name)
+
+	for {
+		if file, ok := w.byPath[path.Join(dir, key)]; ok {
+			return file, true
+		}
+
+		if dir == "." || dir == "/" {
+			return "", false
+		}
+
+		dir = path.Dir(dir)
+	}
+}
+
+// IsWikiSpecialPage checks if filePath is a page that GitHub shows around the
+// others instead of listing it, such as _Sidebar.md.
+func IsWikiSpecialPage(filePath string) bool {
+	return strings.HasPrefix(path.Base(filePath), "_")
+}
+
+// FindWikiHomeFS finds the Home page of the wiki in dir inside fsys.
+func FindWikiHomeFS(fsys fs.FS, dir string) (string, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return "", fmt.Errorf("%w: Home page in %s directory", ErrFileNotFound, dir)
+	}
+
+	for _, f := range files {
+		if !f.IsDir() && IsWikiPage(f.Name()) && wikiPageKey(WikiPageName(f.Name())) == "home" {
+			return path.Join(dir, f.Name()), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: Home page in %s directory", ErrFileNotFound, dir)
+}
+
+type wikiLinkExtender struct {
+	wiki     *WikiPages
+	basePath string
+}
+
+// newWikiLinkExtender returns an extension that renders [[Page Name]] and
+// [[Link text|Page Name]] links to the pages of wiki, and [[image.png]] or
+// [[image.png|alt=Text]] as images. Links are absolute, under basePath.
+func newWikiLinkExtender(wiki *WikiPages, basePath string) *wikiLinkExtender {
+	return &wikiLinkExtender{wiki: wiki, basePath: basePath}
+}
+
+func (e *wikiLinkExtender) Extend(m goldmark.Markdown) {
+	// before the link parser (200), which would take [[ as a link label
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(&wikiLinkParser{wiki: e.wiki, basePath: e.basePath}, 199),
+	))
+}
+
+type wikiLinkParser struct {
+	wiki     *WikiPages
+	basePath string
+}
+
+func (p *wikiLinkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (p *wikiLinkParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+
+	end := bytes.Index(line[2:], []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+
+	content := strings.TrimSpace(string(line[2 : 2+end]))
+	if content == "" || strings.ContainsAny(content, "[]") {
+		return nil
+	}
+
+	block.Advance(end + 4)
+
+	parts := strings.Split(content, "|")
+	if slices.Contains(wikiImageExtensions, strings.ToLower(path.Ext(parts[0]))) {
+		return p.image(parts)
+	}
+
+	label, target := parts[0], parts[0]
+	if len(parts) > 1 {
+		target = parts[len(parts)-1]
+	}
+
+	return p.link(strings.TrimSpace(label), strings.TrimSpace(target))
+}
+
+func (p *wikiLinkParser) link(label, target string) ast.Node {
+	page, anchor, _ := strings.Cut(target, "#")
+
+	link := ast.NewLink()
+	link.AppendChild(link, ast.NewString([]byte(label)))
+
+	if anchor != "" {
+		anchor = "#" + wikiPageKey(anchor)
+	}
+
+	switch file, ok := p.wiki.Resolve(page); {
+	case page == "":
+		link.Destination = []byte(anchor)
+	case ok:
+		link.Destination = []byte(p.basePath + "/" + file + anchor)
+	default:
+		link.Destination = []byte(p.basePath + "/" + wikiPageKey(page) + anchor)
+		link.SetAttributeString("class", []byte("wiki-link-missing"))
+		link.Title = []byte("Page not found")
+	}
+
+	return link
+}
+
+func (p *wikiLinkParser) image(parts []string) ast.Node {
+	src := strings.TrimSpace(parts[0])
+	if !strings.Contains(src, "://") {
+		src = p.basePath + "/" + strings.TrimPrefix(src, "/")
+	}
+
+	link := ast.NewLink()
+	link.Destination = []byte(src)
+
+	image := ast.NewImage(link)
+
+	for _, option := range parts[1:] {
+		if alt, ok := strings.CutPrefix(strings.TrimSpace(option), "alt="); ok {
+			image.AppendChild(image, ast.NewString([]byte(alt)))
+		}
+	}
+
+	return image
+}
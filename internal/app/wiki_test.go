@@ -0,0 +1,93 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func newTestWiki(t *testing.T) *WikiPages {
+	t.Helper()
+
+	wiki, err := NewWikiPages(fstest.MapFS{
+		"Home.md":                 {Data: []byte("# Home")},
+		"Getting-Started.md":      {Data: []byte("# Getting started")},
+		"_Sidebar.md":             {Data: []byte("sidebar")},
+		"_Footer.md":              {Data: []byte("footer")},
+		"guides/Advanced-Use.rst": {Data: []byte("Advanced")},
+		"guides/_Sidebar.md":      {Data: []byte("guides sidebar")},
+		"images/logo.png":         {Data: []byte("png")},
+		".git/HEAD.md":            {Data: []byte("hidden")},
+	})
+	assert.Nil(t, err)
+
+	return wiki
+}
+
+func TestWikiPages(t *testing.T) {
+	wiki := newTestWiki(t)
+
+	assert.DeepEqual(t, wiki.Pages(), []WikiPage{
+		{Name: "Advanced Use", Path: "guides/Advanced-Use.rst"},
+		{Name: "Getting Started", Path: "Getting-Started.md"},
+		{Name: "Home", Path: "Home.md"},
+	})
+
+	file, ok := wiki.Resolve("getting started")
+	assert.True(t, ok)
+	assert.Equal(t, file, "Getting-Started.md")
+
+	file, ok = wiki.Resolve("Advanced-Use")
+	assert.True(t, ok)
+	assert.Equal(t, file, "guides/Advanced-Use.rst")
+
+	_, ok = wiki.Resolve("HEAD")
+	assert.False(t, ok)
+
+	file, ok = wiki.Nearest("guides", "_Sidebar")
+	assert.True(t, ok)
+	assert.Equal(t, file, "guides/_Sidebar.md")
+
+	file, ok = wiki.Nearest("guides", "_Footer")
+	assert.True(t, ok)
+	assert.Equal(t, file, "_Footer.md")
+
+	_, ok = wiki.Nearest(".", "_Header")
+	assert.False(t, ok)
+}
+
+func TestFindWikiHomeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md": {Data: []byte("readme")},
+		"home.md":   {Data: []byte("home")},
+	}
+
+	home, err := FindWikiHomeFS(fsys, ".")
+	assert.Nil(t, err)
+	assert.Equal(t, home, "home.md")
+
+	_, err = FindWikiHomeFS(fstest.MapFS{"README.md": {Data: []byte("readme")}}, ".")
+	assert.NotNil(t, err)
+}
+
+func TestWikiLinks(t *testing.T) {
+	markdown := "[[Getting Started]], [[the guide|advanced use#First Steps]], [[Missing Page]], " +
+		"[[images/logo.png|alt=Logo]] and [a link](Home.md) [[not closed\n"
+
+	html, err := ToHTML(markdown, false, WithWikiLinks(newTestWiki(t), "/preview"))
+	assert.Nil(t, err)
+
+	assert.True(t, strings.Contains(html, `<a href="/preview/Getting-Started.md">Getting Started</a>`))
+	assert.True(t, strings.Contains(html, `<a href="/preview/guides/Advanced-Use.rst#first-steps">the guide</a>`))
+	assert.True(t, strings.Contains(html, `<a href="/preview/missing-page" title="Page not found" class="wiki-link-missing">Missing Page</a>`))
+	assert.True(t, strings.Contains(html, `<img src="/preview/images/logo.png" alt="Logo">`))
+	assert.True(t, strings.Contains(html, `<a href="Home.md">a link</a>`))
+	assert.True(t, strings.Contains(html, "[[not closed"))
+
+	// Wiki links are only rendered in wiki mode
+	html, err = ToHTML("[[Getting Started]]", false)
+	assert.Nil(t, err)
+	assert.Equal(t, html, "<p>[[Getting Started]]</p>\n")
+}
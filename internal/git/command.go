@@ -0,0 +1,148 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const commandTimeout = 10 * time.Second
+
+var (
+	ErrNotInstalled = errors.New("git is not installed")
+	ErrInvalidRef   = errors.New("invalid git ref")
+	ErrNoRevision   = errors.New("no previous revision")
+)
+
+var refRegexp = regexp.MustCompile(`^[A-Za-z0-9_./@^~{}+-]+$`)
+
+// ValidRef reports whether ref looks like a revision (branch, tag, commit,
+// or expressions such as "HEAD~2") that can be passed safely to git.
+func ValidRef(ref string) bool {
+	return refRegexp.MatchString(ref) && !strings.HasPrefix(ref, "-") && !strings.Contains(ref, "..")
+}
+
+// run executes git in dir and returns its standard output.
+func run(dir string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrNotInstalled
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// runLines executes git in dir and calls fn with each line of its standard
+// output, stopping git early once fn returns false.
+func runLines(dir string, fn func(line string) bool, args ...string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return ErrNotInstalled
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if !fn(scanner.Text()) {
+			// the output that is left isn't needed
+			cancel()
+			_ = cmd.Wait()
+
+			return nil
+		}
+	}
+
+	scanErr := scanner.Err()
+	if scanErr != nil {
+		cancel()
+	}
+
+	err = cmd.Wait()
+	if scanErr != nil {
+		return fmt.Errorf("git %s: %w", args[0], scanErr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// Show returns the contents of file at ref, e.g. "HEAD".
+func Show(file, ref string) ([]byte, error) {
+	if !ValidRef(ref) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+
+	return run(filepath.Dir(file), "show", "--end-of-options", ref+":./"+filepath.Base(file))
+}
+
+// PreviousRevision returns the revision holding the previous version of file:
+// HEAD when the file has uncommitted changes, otherwise the commit before the
+// last one that changed it.
+func PreviousRevision(file string) (string, error) {
+	dir, name := filepath.Dir(file), filepath.Base(file)
+
+	status, err := run(dir, "--no-optional-locks", "status", "--porcelain", "--", name)
+	if err != nil {
+		return "", err
+	}
+
+	if len(status) > 0 {
+		// Untracked or newly added files have no committed version
+		if bytes.HasPrefix(status, []byte("??")) || bytes.HasPrefix(status, []byte("A")) {
+			return "", fmt.Errorf("%w: %s is not committed", ErrNoRevision, file)
+		}
+
+		return "HEAD", nil
+	}
+
+	log, err := run(dir, "log", "-n", "2", "--format=%H", "--", name)
+	if err != nil {
+		return "", err
+	}
+
+	commits := strings.Fields(string(log))
+	if len(commits) < 2 {
+		return "", fmt.Errorf("%w: %s", ErrNoRevision, file)
+	}
+
+	return commits[1], nil
+}
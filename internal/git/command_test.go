@@ -0,0 +1,60 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/gittest"
+)
+
+func TestValidRef(t *testing.T) {
+	for _, ref := range []string{"HEAD", "HEAD~2", "main", "v1.0.0", "feature/x", "0a1b2c3", "HEAD@{1}"} {
+		assert.True(t, ValidRef(ref))
+	}
+
+	for _, ref := range []string{"", "--output=/tmp/x", "-p", "main..HEAD", "a b", "HEAD:file"} {
+		assert.False(t, ValidRef(ref))
+	}
+}
+
+func TestShowAndPreviousRevision(t *testing.T) {
+	dir := gittest.NewRepository(t)
+	file := filepath.Join(dir, "image.txt")
+
+	gittest.CommitFile(t, dir, "image.txt", "v1")
+	first := gittest.Command(t, dir, "rev-parse", "HEAD")
+
+	_, err := PreviousRevision(file)
+	assert.True(t, errors.Is(err, ErrNoRevision))
+
+	gittest.CommitFile(t, dir, "image.txt", "v2")
+
+	ref, err := PreviousRevision(file)
+	assert.Nil(t, err)
+	assert.Equal(t, ref+"\n", first)
+
+	content, err := Show(file, ref)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "v1")
+
+	assert.Nil(t, os.WriteFile(file, []byte("v3"), 0o600))
+
+	ref, err = PreviousRevision(file)
+	assert.Nil(t, err)
+	assert.Equal(t, ref, "HEAD")
+
+	content, err = Show(file, ref)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "v2")
+
+	_, err = Show(file, "--output=/tmp/x")
+	assert.True(t, errors.Is(err, ErrInvalidRef))
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0o600))
+
+	_, err = PreviousRevision(filepath.Join(dir, "new.txt"))
+	assert.True(t, errors.Is(err, ErrNoRevision))
+}
@@ -0,0 +1,263 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnknownRef = errors.New("unknown git ref")
+
+// FS is a read-only fs.FS of a directory as of a git revision, read from the
+// local object database with the git command. Directory listings are cached,
+// since a revision never changes.
+type FS struct {
+	dir     string
+	commit  string
+	modTime time.Time
+
+	mu    sync.Mutex
+	trees map[string][]fs.DirEntry
+}
+
+// NewFS returns the contents of dir, which must be inside a git repository,
+// at ref (a branch, tag or commit).
+func NewFS(dir, ref string) (*FS, error) {
+	if !ValidRef(ref) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+
+	out, err := run(dir, "log", "-1", "--format=%H %ct", "--end-of-options", ref, "--")
+	if errors.Is(err, ErrNotInstalled) {
+		return nil, err
+	}
+
+	commit, timestamp, ok := strings.Cut(strings.TrimSpace(string(out)), " ")
+	if err != nil || !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRef, ref)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("commit time error: %w", err)
+	}
+
+	return &FS{dir: dir, commit: commit, modTime: time.Unix(seconds, 0), trees: map[string][]fs.DirEntry{}}, nil
+}
+
+// Commit returns the hash of the commit the FS refers to.
+func (f *FS) Commit() string {
+	return f.commit
+}
+
+func (f *FS) object(name string) string {
+	if name == "." {
+		return f.commit + ":./"
+	}
+
+	return f.commit + ":./" + name
+}
+
+// Open implements fs.FS.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	if info.IsDir() {
+		entries, err := f.ReadDir(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+
+		return &dirFile{info: info, entries: entries}, nil
+	}
+
+	content, err := f.ReadFile(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &blobFile{info: info, Reader: bytes.NewReader(content)}, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+
+	content, err := run(f.dir, "cat-file", "blob", f.object(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return content, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entries, ok := f.trees[name]
+	if !ok {
+		var err error
+
+		entries, err = f.readTree(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+		}
+
+		f.trees[name] = entries
+	}
+
+	return slices.Clone(entries), nil
+}
+
+// readTree lists the entries of the directory name, sorted by name.
+func (f *FS) readTree(name string) ([]fs.DirEntry, error) {
+	// --full-tree, otherwise entries are filtered by the path of dir inside
+	// the repository
+	out, err := run(f.dir, "ls-tree", "--full-tree", "-l", "-z", f.object(name))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []fs.DirEntry
+
+	for line := range strings.SplitSeq(strings.TrimSuffix(string(out), "\x00"), "\x00") {
+		info, ok := f.parseTreeEntry(line)
+		if ok {
+			entries = append(entries, fs.FileInfoToDirEntry(info))
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries, nil
+}
+
+// Stat implements fs.StatFS.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return &fileInfo{name: ".", mode: fs.ModeDir | 0o555, modTime: f.modTime}, nil
+	}
+
+	entries, err := f.ReadDir(path.Dir(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == path.Base(name) {
+			return entry.Info() //nolint:wrapcheck // fileInfo never fails
+		}
+	}
+
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// parseTreeEntry parses a "<mode> <type> <object> <size>\t<name>" line of
+// "git ls-tree -l". Submodules are skipped since their contents aren't in
+// the repository.
+func (f *FS) parseTreeEntry(line string) (*fileInfo, bool) {
+	meta, name, ok := strings.Cut(line, "\t")
+	fields := strings.Fields(meta)
+
+	if !ok || len(fields) != 4 {
+		return nil, false
+	}
+
+	info := &fileInfo{name: name, modTime: f.modTime}
+
+	switch fields[1] {
+	case "tree":
+		info.mode = fs.ModeDir | 0o555
+	case "blob":
+		info.mode = 0o444
+		if fields[0] == "100755" {
+			info.mode = 0o555
+		}
+
+		info.size, _ = strconv.ParseInt(fields[3], 10, 64)
+	default:
+		return nil, false
+	}
+
+	return info, true
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *fileInfo) Sys() any           { return nil }
+
+type blobFile struct {
+	*bytes.Reader
+
+	info fs.FileInfo
+}
+
+func (b *blobFile) Stat() (fs.FileInfo, error) { return b.info, nil }
+func (b *blobFile) Close() error               { return nil }
+
+type dirFile struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+
+	return entries, nil
+}
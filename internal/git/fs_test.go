@@ -0,0 +1,75 @@
+package git
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/gittest"
+)
+
+func TestFS(t *testing.T) {
+	dir := gittest.NewRepository(t)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "docs", "guide"), 0o755))
+	gittest.CommitFile(t, dir, "README.md", "# Old")
+	gittest.CommitFile(t, dir, "docs/index.md", "# Docs")
+	gittest.CommitFile(t, dir, "docs/guide/intro.md", "# Intro")
+	gittest.Command(t, dir, "tag", "v1.0")
+
+	gittest.CommitFile(t, dir, "README.md", "# New")
+	gittest.CommitFile(t, dir, "added.md", "# Added")
+
+	fsys, err := NewFS(dir, "v1.0")
+	assert.Nil(t, err)
+
+	err = fstest.TestFS(fsys, "README.md", "docs/index.md", "docs/guide/intro.md")
+	assert.Nil(t, err)
+
+	content, err := fs.ReadFile(fsys, "README.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "# Old")
+
+	_, err = fs.Stat(fsys, "added.md")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	// Paths are relative to the directory, not the repository
+	subFS, err := NewFS(filepath.Join(dir, "docs"), "main")
+	assert.Nil(t, err)
+
+	err = fstest.TestFS(subFS, "index.md", "guide/intro.md")
+	assert.Nil(t, err)
+
+	_, err = NewFS(dir, "missing")
+	assert.True(t, errors.Is(err, ErrUnknownRef))
+
+	_, err = NewFS(dir, "--all")
+	assert.True(t, errors.Is(err, ErrInvalidRef))
+}
+
+func TestFSCachesTrees(t *testing.T) {
+	dir := gittest.NewRepository(t)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "docs"), 0o755))
+	gittest.CommitFile(t, dir, "docs/index.md", "# Docs")
+
+	fsys, err := NewFS(dir, "HEAD")
+	assert.Nil(t, err)
+
+	_, err = fsys.ReadDir("docs")
+	assert.Nil(t, err)
+
+	// listed directories are read without git
+	assert.Nil(t, os.Rename(filepath.Join(dir, ".git"), filepath.Join(dir, "git")))
+
+	info, err := fsys.Stat("docs/index.md")
+	assert.Nil(t, err)
+	assert.Equal(t, info.Size(), int64(6))
+
+	_, err = fsys.Stat("README.md")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
@@ -0,0 +1,171 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrNotRepository = errors.New("not a git repository")
+	ErrNoRemote      = errors.New("no GitHub remote found")
+)
+
+var (
+	sectionRegexp      = regexp.MustCompile(`^\[\s*([^\s\]"]+)(?:\s+"((?:[^"\\]|\\.)*)")?\s*\]$`)
+	githubRemoteRegexp = regexp.MustCompile(`^(?:(?:https?|git|ssh)://(?:[^@/]+@)?github\.com(?::[0-9]+)?/|[^@/]+@github\.com:)([A-Za-z0-9][A-Za-z0-9-]*)/([A-Za-z0-9._-]+?)(?:\.git)?/?$`)
+)
+
+// FindGitDir returns the git directory of the repository containing dir,
+// following "gitdir:" files used by worktrees and submodules.
+func FindGitDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("absolute path error: %w", err)
+	}
+
+	for {
+		candidate := filepath.Join(abs, ".git")
+
+		info, err := os.Stat(candidate)
+		if err == nil {
+			if info.IsDir() {
+				return candidate, nil
+			}
+
+			return readGitDirFile(candidate)
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", fmt.Errorf("%w: %s", ErrNotRepository, dir)
+		}
+
+		abs = parent
+	}
+}
+
+func readGitDirFile(file string) (string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read gitdir file error: %w", err)
+	}
+
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%w: invalid gitdir file %s", ErrNotRepository, file)
+	}
+
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(file), gitDir)
+	}
+
+	return gitDir, nil
+}
+
+// commonDir returns the directory holding the shared repository data (e.g.
+// config) for gitDir, which differs from gitDir for linked worktrees.
+func commonDir(gitDir string) string {
+	b, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+
+	dir := strings.TrimSpace(string(b))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+
+	return dir
+}
+
+// RemoteRepository returns the "owner/name" slug of the GitHub remote of the
+// repository containing dir, read from its local git config. The "origin"
+// remote is preferred, otherwise the first GitHub remote is used.
+func RemoteRepository(dir string) (string, error) {
+	gitDir, err := FindGitDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(filepath.Join(commonDir(gitDir), "config"))
+	if err != nil {
+		return "", fmt.Errorf("git config open error: %w", err)
+	}
+	defer f.Close()
+
+	remotes, order, err := parseRemoteURLs(f)
+	if err != nil {
+		return "", err
+	}
+
+	if slug, ok := githubSlug(remotes["origin"]); ok {
+		return slug, nil
+	}
+
+	for _, name := range order {
+		if slug, ok := githubSlug(remotes[name]); ok {
+			return slug, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrNoRemote, dir)
+}
+
+func parseRemoteURLs(r io.Reader) (map[string]string, []string, error) {
+	remotes := map[string]string{}
+
+	var (
+		order   []string
+		current string
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if m := sectionRegexp.FindStringSubmatch(line); m != nil {
+			current = ""
+			if strings.EqualFold(m[1], "remote") {
+				current = m[2]
+			}
+
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if current == "" || !ok || !strings.EqualFold(strings.TrimSpace(key), "url") {
+			continue
+		}
+
+		if _, seen := remotes[current]; !seen {
+			order = append(order, current)
+		}
+
+		remotes[current] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("git config read error: %w", err)
+	}
+
+	return remotes, order, nil
+}
+
+func githubSlug(remoteURL string) (string, bool) {
+	m := githubRemoteRegexp.FindStringSubmatch(remoteURL)
+	if m == nil {
+		return "", false
+	}
+
+	return m[1] + "/" + m[2], true
+}
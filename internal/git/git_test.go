@@ -0,0 +1,98 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func writeGitConfig(t *testing.T, dir, config string) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755)
+	assert.Nil(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, ".git", "config"), []byte(config), 0o600)
+	assert.Nil(t, err)
+}
+
+func TestRemoteRepository(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name:   "SSH origin",
+			config: "[core]\n\tbare = false\n[remote \"origin\"]\n\turl = git@github.com:thiagokokada/gh-gfm-preview.git\n",
+			want:   "thiagokokada/gh-gfm-preview",
+		},
+		{
+			name:   "HTTPS origin without suffix",
+			config: "[remote \"origin\"]\n\turl = https://github.com/yuin/goldmark\n",
+			want:   "yuin/goldmark",
+		},
+		{
+			name:   "SSH URL",
+			config: "[remote \"origin\"]\n\turl = ssh://git@github.com/owner/repo.name.git\n",
+			want:   "owner/repo.name",
+		},
+		{
+			name: "Prefers origin",
+			config: "[remote \"upstream\"]\n\turl = https://github.com/upstream/repo.git\n" +
+				"[remote \"origin\"]\n\turl = https://github.com/fork/repo.git\n",
+			want: "fork/repo",
+		},
+		{
+			name: "Falls back to first GitHub remote",
+			config: "[remote \"origin\"]\n\turl = https://gitlab.com/owner/repo.git\n" +
+				"[remote \"mirror\"]\n\turl = git@github.com:owner/mirror.git\n",
+			want: "owner/mirror",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeGitConfig(t, dir, tt.config)
+
+			subdir := filepath.Join(dir, "docs")
+			err := os.Mkdir(subdir, 0o755)
+			assert.Nil(t, err)
+
+			got, err := RemoteRepository(subdir)
+			assert.Nil(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestRemoteRepositoryWithoutGitHubRemote(t *testing.T) {
+	dir := t.TempDir()
+	writeGitConfig(t, dir, "[remote \"origin\"]\n\turl = https://example.com/owner/repo.git\n")
+
+	_, err := RemoteRepository(dir)
+	assert.True(t, errors.Is(err, ErrNoRemote))
+}
+
+func TestFindGitDirFollowsGitDirFile(t *testing.T) {
+	dir := t.TempDir()
+	gitDir := filepath.Join(dir, "real.git")
+
+	err := os.Mkdir(gitDir, 0o755)
+	assert.Nil(t, err)
+
+	worktree := filepath.Join(dir, "worktree")
+	err = os.Mkdir(worktree, 0o755)
+	assert.Nil(t, err)
+
+	err = os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: ../real.git\n"), 0o600)
+	assert.Nil(t, err)
+
+	got, err := FindGitDir(worktree)
+	assert.Nil(t, err)
+	assert.Equal(t, got, gitDir)
+}
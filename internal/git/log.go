@@ -0,0 +1,162 @@
+package git
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commitMarker starts the commit lines of LastCommits, separating them from
+// the file names.
+const commitMarker = "\x1e"
+
+// CommitInfo describes a commit, as shown next to the files it changed.
+type CommitInfo struct {
+	Hash    string
+	Subject string
+	Time    time.Time
+}
+
+// LastCommits returns the last commit as of ref (e.g. "HEAD") that changed
+// each of names, the files and directories of dir. Names that are not in
+// ref, like untracked or newly added files, are left out. On errors such as
+// a timeout, the commits found until then are returned along with the error.
+func LastCommits(dir, ref string, names []string) (map[string]CommitInfo, error) {
+	if !ValidRef(ref) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+
+	commits := make(map[string]CommitInfo, len(names))
+
+	committed, err := treeNames(dir, ref)
+	if err != nil {
+		return commits, err
+	}
+
+	// names that aren't in ref would be searched through the whole history
+	wanted := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		if committed[name] {
+			wanted[name] = true
+		}
+	}
+
+	if len(wanted) == 0 {
+		return commits, nil
+	}
+
+	var current CommitInfo
+
+	err = runLines(dir, func(line string) bool {
+		if header, ok := strings.CutPrefix(line, commitMarker); ok {
+			current = parseCommitLine(header)
+
+			return true
+		}
+
+		// file names are relative to dir, its entries are their first
+		// component
+		name, _, _ := strings.Cut(line, "/")
+		if _, found := commits[name]; wanted[name] && !found {
+			commits[name] = current
+		}
+
+		return len(commits) < len(wanted)
+	}, "-c", "core.quotePath=false", "log", "--format="+commitMarker+"%H%x00%ct%x00%s",
+		"--name-only", "--relative", "--no-renames", "--end-of-options", ref, "--", ".")
+
+	return commits, err
+}
+
+// CommitCache keeps the results of LastCommits for the commit its ref points
+// to, since they only change with new commits. Only the last results of each
+// directory are kept. A nil cache doesn't keep anything.
+type CommitCache struct {
+	mu      sync.Mutex
+	entries map[string]commitCacheEntry
+}
+
+type commitCacheEntry struct {
+	key     string
+	commits map[string]CommitInfo
+}
+
+// LastCommits is like the LastCommits function, reusing the results found for
+// the same commit and names.
+func (c *CommitCache) LastCommits(dir, ref string, names []string) (map[string]CommitInfo, error) {
+	if c == nil {
+		return LastCommits(dir, ref, names)
+	}
+
+	if !ValidRef(ref) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+
+	out, err := run(dir, "log", "-1", "--format=%H", "--end-of-options", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	commit := strings.TrimSpace(string(out))
+	key := commit + "\x00" + strings.Join(names, "\x00")
+
+	c.mu.Lock()
+	entry, ok := c.entries[dir]
+	c.mu.Unlock()
+
+	if ok && entry.key == key {
+		return maps.Clone(entry.commits), nil
+	}
+
+	commits, err := LastCommits(dir, commit, names)
+	if err != nil {
+		// partial results are looked up again next time
+		return commits, err
+	}
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string]commitCacheEntry{}
+	}
+
+	c.entries[dir] = commitCacheEntry{key: key, commits: commits}
+	c.mu.Unlock()
+
+	return maps.Clone(commits), nil
+}
+
+// treeNames returns the names of the entries of dir as of ref.
+func treeNames(dir, ref string) (map[string]bool, error) {
+	out, err := run(dir, "ls-tree", "-z", "--name-only", "--end-of-options", ref)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+
+	for name := range strings.SplitSeq(string(out), "\x00") {
+		if name != "" {
+			names[name] = true
+		}
+	}
+
+	return names, nil
+}
+
+func parseCommitLine(line string) CommitInfo {
+	hash, rest, _ := strings.Cut(line, "\x00")
+	timestamp, subject, _ := strings.Cut(rest, "\x00")
+
+	info := CommitInfo{Hash: hash, Subject: subject}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err == nil {
+		info.Time = time.Unix(seconds, 0)
+	}
+
+	return info
+}
@@ -0,0 +1,83 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/gittest"
+)
+
+func TestLastCommits(t *testing.T) {
+	dir := gittest.NewRepository(t)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "docs", "guide"), 0o755))
+	gittest.CommitFile(t, dir, "README.md", "# Old")
+	gittest.CommitFile(t, dir, "docs/guide/intro.md", "# Intro")
+	gittest.CommitFile(t, dir, "docs/index.md", "# Docs")
+	gittest.Command(t, dir, "tag", "v1.0")
+	gittest.CommitFile(t, dir, "README.md", "# New")
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "untracked.md"), []byte("new"), 0o600))
+	// staged directories are reported as modified by git status
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "staged"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "staged", "new.md"), []byte("new"), 0o600))
+	gittest.Command(t, dir, "add", "staged")
+
+	commits, err := LastCommits(dir, "HEAD", []string{"README.md", "docs", "untracked.md", "staged"})
+	assert.Nil(t, err)
+	assert.Equal(t, len(commits), 2)
+	assert.Equal(t, commits["README.md"].Subject, "Update README.md")
+	assert.Equal(t, commits["docs"].Subject, "Update docs/index.md")
+	assert.Equal(t, len(commits["docs"].Hash), 40)
+	assert.False(t, commits["docs"].Time.IsZero())
+
+	commits, err = LastCommits(dir, "v1.0", []string{"README.md"})
+	assert.Nil(t, err)
+	assert.Equal(t, commits["README.md"].Subject, "Update README.md")
+	assert.True(t, commits["README.md"].Hash != gittest.Command(t, dir, "rev-parse", "HEAD")[:40])
+
+	// Names are relative to dir
+	commits, err = LastCommits(filepath.Join(dir, "docs"), "HEAD", []string{"guide", "index.md", "README.md"})
+	assert.Nil(t, err)
+	assert.Equal(t, len(commits), 2)
+	assert.Equal(t, commits["guide"].Subject, "Update docs/guide/intro.md")
+
+	_, err = LastCommits(dir, "--all", []string{"README.md"})
+	assert.True(t, errors.Is(err, ErrInvalidRef))
+}
+
+func TestCommitCache(t *testing.T) {
+	dir := gittest.NewRepository(t)
+
+	gittest.CommitFile(t, dir, "README.md", "# Old")
+
+	var cache *CommitCache
+
+	commits, err := cache.LastCommits(dir, "HEAD", []string{"README.md"})
+	assert.Nil(t, err)
+	assert.Equal(t, commits["README.md"].Subject, "Update README.md")
+
+	cache = &CommitCache{}
+
+	commits, err = cache.LastCommits(dir, "HEAD", []string{"README.md"})
+	assert.Nil(t, err)
+	assert.Equal(t, commits["README.md"].Subject, "Update README.md")
+
+	head := strings.TrimSpace(gittest.Command(t, dir, "rev-parse", "HEAD"))
+	assert.True(t, strings.HasPrefix(cache.entries[dir].key, head))
+
+	// a new commit is looked up again
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# New"), 0o600))
+	gittest.Command(t, dir, "commit", "--quiet", "--all", "--message", "Rewrite README")
+
+	commits, err = cache.LastCommits(dir, "HEAD", []string{"README.md"})
+	assert.Nil(t, err)
+	assert.Equal(t, commits["README.md"].Subject, "Rewrite README")
+	assert.Equal(t, len(cache.entries), 1)
+
+	_, err = cache.LastCommits(dir, "--all", []string{"README.md"})
+	assert.True(t, errors.Is(err, ErrInvalidRef))
+}
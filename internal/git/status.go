@@ -0,0 +1,140 @@
+package git
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// FileStatus is the state of a file in the working tree compared to HEAD.
+type FileStatus string
+
+const (
+	StatusModified  FileStatus = "modified"
+	StatusAdded     FileStatus = "added"
+	StatusDeleted   FileStatus = "deleted"
+	StatusUntracked FileStatus = "untracked"
+	StatusIgnored   FileStatus = "ignored"
+)
+
+// Status holds the files of a directory that differ from HEAD, by their path
+// relative to the directory. Ignored and untracked directories are stored
+// with a trailing slash, as git reports them.
+type Status struct {
+	files map[string]FileStatus
+}
+
+// ReadStatus returns the status of the files in dir, which must be inside a
+// git repository.
+func ReadStatus(dir string) (*Status, error) {
+	prefix, err := run(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+
+	// git status otherwise refreshes the index while holding index.lock,
+	// making the user's own git commands fail at the same time
+	out, err := run(dir, "--no-optional-locks", "status", "--porcelain=v1", "-z", "--untracked-files=all", "--ignored=matching", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStatus(string(out), strings.TrimSpace(string(prefix))), nil
+}
+
+// parseStatus parses the output of "git status --porcelain -z", whose paths
+// are relative to the repository root, keeping the files below prefix.
+func parseStatus(out, prefix string) *Status {
+	s := &Status{files: map[string]FileStatus{}}
+	entries := strings.Split(out, "\x00")
+
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		code, name := entry[:2], entry[3:]
+
+		// renames and copies are followed by the original path
+		if code[0] == 'R' || code[0] == 'C' {
+			i++
+		}
+
+		name, ok := strings.CutPrefix(name, prefix)
+		if ok && name != "" {
+			s.files[name] = statusOf(code)
+		}
+	}
+
+	return s
+}
+
+func statusOf(code string) FileStatus {
+	switch {
+	case code == "??":
+		return StatusUntracked
+	case code == "!!":
+		return StatusIgnored
+	case strings.ContainsAny(code, "ARC"):
+		return StatusAdded
+	case strings.Contains(code, "D"):
+		return StatusDeleted
+	default:
+		return StatusModified
+	}
+}
+
+// Of returns the status of the file or directory name, or an empty string if
+// it is unchanged. Directories containing changes are reported as modified.
+func (s *Status) Of(name string, isDir bool) FileStatus {
+	if s == nil {
+		return ""
+	}
+
+	if status, ok := s.files[name]; ok && !isDir {
+		return status
+	}
+
+	for dir := name; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if status, ok := s.files[dir+"/"]; ok {
+			return status
+		}
+	}
+
+	if !isDir {
+		return ""
+	}
+
+	for file, status := range s.files {
+		if strings.HasPrefix(file, name+"/") && status != StatusIgnored {
+			return StatusModified
+		}
+	}
+
+	return ""
+}
+
+// Changed returns the files below dir ("." for all) that were modified, added
+// or are untracked, sorted by path.
+func (s *Status) Changed(dir string) []string {
+	if s == nil {
+		return nil
+	}
+
+	var files []string
+
+	for file, status := range s.files {
+		if status == StatusIgnored || status == StatusDeleted || strings.HasSuffix(file, "/") {
+			continue
+		}
+
+		if dir == "." || strings.HasPrefix(file, dir+"/") {
+			files = append(files, file)
+		}
+	}
+
+	slices.Sort(files)
+
+	return files
+}
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/gittest"
+)
+
+func TestReadStatus(t *testing.T) {
+	dir := gittest.NewRepository(t)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "docs", "sub"), 0o755))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "docs", "build"), 0o755))
+	gittest.CommitFile(t, dir, ".gitignore", "build/\n*.log\n")
+	gittest.CommitFile(t, dir, "docs/a.md", "a")
+	gittest.CommitFile(t, dir, "docs/b.md", "b")
+	gittest.CommitFile(t, dir, "docs/c.md", "c")
+	gittest.CommitFile(t, dir, "docs/same.md", "same")
+	gittest.CommitFile(t, dir, "other.md", "other")
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "docs", "a.md"), []byte("changed"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "docs", "sub", "new.md"), []byte("new"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "docs", "debug.log"), []byte("log"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "docs", "build", "out.html"), []byte("out"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "other.md"), []byte("changed"), 0o600))
+	gittest.Command(t, dir, "mv", "docs/b.md", "docs/renamed.md")
+	gittest.Command(t, dir, "rm", "--quiet", "docs/c.md")
+
+	status, err := ReadStatus(filepath.Join(dir, "docs"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, status.Of("a.md", false), StatusModified)
+	assert.Equal(t, status.Of("renamed.md", false), StatusAdded)
+	assert.Equal(t, status.Of("c.md", false), StatusDeleted)
+	assert.Equal(t, status.Of("sub/new.md", false), StatusUntracked)
+	assert.Equal(t, status.Of("sub", true), StatusModified)
+	assert.Equal(t, status.Of("debug.log", false), StatusIgnored)
+	assert.Equal(t, status.Of("build", true), StatusIgnored)
+	assert.Equal(t, status.Of("build/out.html", false), StatusIgnored)
+	assert.Equal(t, status.Of("same.md", false), FileStatus(""))
+	assert.Equal(t, status.Of("b.md", false), FileStatus(""))
+
+	assert.DeepEqual(t, status.Changed("."), []string{"a.md", "renamed.md", "sub/new.md"})
+	assert.DeepEqual(t, status.Changed("sub"), []string{"sub/new.md"})
+
+	var nilStatus *Status
+	assert.Equal(t, nilStatus.Of("a.md", false), FileStatus(""))
+}
@@ -0,0 +1,67 @@
+// Package gittest creates git repositories for tests.
+package gittest
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// NewRepository returns an empty repository in a temporary directory. The
+// test is skipped if git isn't installed.
+func NewRepository(tb testing.TB) string {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	Init(tb, dir)
+
+	return dir
+}
+
+// Init creates a repository in dir, on the "main" branch, committing as a
+// test user without signing.
+func Init(tb testing.TB, dir string) {
+	tb.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		tb.Skip("git is not installed")
+	}
+
+	Command(tb, dir, "init", "--quiet", "--initial-branch=main")
+	Command(tb, dir, "config", "user.name", "Test")
+	Command(tb, dir, "config", "user.email", "test@example.com")
+	Command(tb, dir, "config", "commit.gpgsign", "false")
+}
+
+// Command runs git with args in dir and returns its output, failing the test
+// on errors.
+func Command(tb testing.TB, dir string, args ...string) string {
+	tb.Helper()
+
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		tb.Fatalf("git %v: %v: %s", args, err, stderr.String())
+	}
+
+	return string(out)
+}
+
+// CommitFile writes content to name in dir and commits it.
+func CommitFile(tb testing.TB, dir, name, content string) {
+	tb.Helper()
+
+	err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+	if err != nil {
+		tb.Fatalf("write %s: %v", name, err)
+	}
+
+	Command(tb, dir, "add", name)
+	Command(tb, dir, "commit", "--quiet", "--message", "Update "+name)
+}
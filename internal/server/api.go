@@ -0,0 +1,269 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/app"
+	"github.com/thiagokokada/gh-gfm-preview/internal/git"
+)
+
+// apiPrefix is where the JSON API is mounted. Breaking changes need a new
+// version, so tools built against this one keep working.
+const apiPrefix = "/__/api/v1/"
+
+// maxRenderRequestBytes limits the Markdown posted to the render endpoint.
+const maxRenderRequestBytes = 10 << 20
+
+var (
+	errAPINoRoot      = errors.New("no directory to serve files from when reading stdin")
+	errAPIInvalidMode = errors.New(`mode must be "gfm" or "markdown"`)
+)
+
+// apiHandler serves the JSON API, letting other tools use the preview as a
+// local rendering service.
+func apiHandler(filename string, param *Param) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+apiPrefix+"info", func(w http.ResponseWriter, _ *http.Request) {
+		writeAPIInfo(w, filename, param)
+	})
+	mux.HandleFunc("GET "+apiPrefix+"files", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIFiles(w, r.URL.Query().Get("path"), filename, param.forRequest(r))
+	})
+	mux.HandleFunc("GET "+apiPrefix+"headings", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIHeadings(w, r.URL.Query().Get("path"), filename, param.forRequest(r))
+	})
+	mux.HandleFunc("POST "+apiPrefix+"render", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIRender(w, r, param)
+	})
+	mux.HandleFunc(apiPrefix, func(w http.ResponseWriter, _ *http.Request) {
+		writeAPIError(w, http.StatusNotFound, app.ErrFileNotFound)
+	})
+
+	return mux
+}
+
+func writeAPIInfo(w http.ResponseWriter, filename string, param *Param) {
+	root := filename
+	if param.IsDirectoryMode {
+		root = param.DirectoryPath
+	}
+
+	renderMode := "gfm"
+	if param.MarkdownMode {
+		renderMode = "markdown"
+	}
+
+	writeAPIJSON(w, http.StatusOK, apiInfoJSON{
+		Version:         param.Version,
+		ProtocolVersion: protocolVersion,
+		Root:            root,
+		DirectoryMode:   param.IsDirectoryMode,
+		Stdin:           param.UseStdin,
+		Mode:            renderMode,
+		ColorMode:       param.getMode().String(),
+		Reload:          param.Reload,
+	})
+}
+
+func writeAPIFiles(w http.ResponseWriter, pathParam, filename string, param *Param) {
+	root, closeRoot, err := apiRoot(filename, param)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+
+		return
+	}
+	defer closeRoot()
+
+	dir, ok := normalizeRootPath(pathParam)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("%w: %s", app.ErrFileNotFound, pathParam))
+
+		return
+	}
+
+	fsys, err := param.contentFS(root)
+	if err != nil {
+		writeAPIError(w, apiErrorStatus(err), err)
+
+		return
+	}
+
+	files, dirs, err := app.ListDirectoryContentsFS(fsys, dir, app.ParseExtensions(param.DirectoryListingShowExtensions))
+	if err != nil {
+		writeAPIError(w, apiErrorStatus(err), err)
+
+		return
+	}
+
+	textExtensions := app.ParseExtensions(param.DirectoryListingTextExtensions)
+	entries := make([]apiFileJSON, 0, len(dirs)+len(files))
+
+	for _, name := range append(dirs, files...) {
+		entryPath := path.Join(dir, name)
+
+		info, err := fs.Stat(fsys, entryPath)
+		if err != nil {
+			slog.Debug("Skipping file that cannot be read", "path", entryPath, "error", err)
+
+			continue
+		}
+
+		entries = append(entries, apiFileJSON{
+			Name:    name,
+			Path:    entryPath,
+			IsDir:   info.IsDir(),
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC().Format(time.RFC3339),
+			IsText:  !info.IsDir() && app.IsTextFile(name, textExtensions),
+		})
+	}
+
+	writeAPIJSON(w, http.StatusOK, apiFilesJSON{Path: dir, Files: entries})
+}
+
+func writeAPIHeadings(w http.ResponseWriter, pathParam, filename string, param *Param) {
+	var (
+		view markdownView
+		err  error
+	)
+
+	if pathParam == "" && !param.IsDirectoryMode {
+		var markdown string
+
+		markdown, err = getMarkdown(filename, param)
+		if err == nil {
+			view, err = renderFileOrDiffView(filename, markdown, previousFile(filename), param)
+		}
+	} else {
+		root, closeRoot, rootErr := apiRoot(filename, param)
+		if rootErr != nil {
+			writeAPIError(w, http.StatusNotFound, rootErr)
+
+			return
+		}
+		defer closeRoot()
+
+		view, _, err = renderMarkdownFromOpenedRoot(rootRelativePath(pathParam), root, param)
+	}
+
+	if err != nil {
+		writeAPIError(w, apiErrorStatus(err), err)
+
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, apiHeadingsJSON{Path: pathParam, Headings: apiHeadings(view.HTML)})
+}
+
+func writeAPIRender(w http.ResponseWriter, r *http.Request, param *Param) {
+	var req apiRenderRequestJSON
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRenderRequestBytes)).Decode(&req)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+
+		return
+	}
+
+	isMarkdownMode := param.MarkdownMode
+
+	switch req.Mode {
+	case "":
+	case "gfm":
+		isMarkdownMode = false
+	case "markdown":
+		isMarkdownMode = true
+	default:
+		writeAPIError(w, http.StatusBadRequest, errAPIInvalidMode)
+
+		return
+	}
+
+	// task checkboxes of posted Markdown have no file to be written back to
+	renderParam := *param
+	renderParam.InteractiveTasks = false
+
+	html, err := app.ToHTML(req.Markdown, isMarkdownMode, renderParam.renderOptions()...)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, apiRenderJSON{HTML: html, Headings: apiHeadings(html)})
+}
+
+// apiRoot returns the directory files are served from, and a function to
+// release it.
+func apiRoot(filename string, param *Param) (*os.Root, func(), error) {
+	if param.IsDirectoryMode {
+		if param.DirectoryRoot == nil {
+			return nil, nil, errNoDirectoryRoot
+		}
+
+		return param.DirectoryRoot, func() {}, nil
+	}
+
+	if param.UseStdin {
+		return nil, nil, errAPINoRoot
+	}
+
+	root, err := os.OpenRoot(filepath.Dir(filename))
+	if err != nil {
+		return nil, nil, fmt.Errorf("directory root open error: %w", err)
+	}
+
+	return root, func() { root.Close() }, nil
+}
+
+func apiHeadings(html string) []apiHeadingJSON {
+	items := extractHeadingItems(html)
+	headings := make([]apiHeadingJSON, 0, len(items))
+
+	for _, item := range items {
+		headings = append(headings, apiHeadingJSON(item))
+	}
+
+	return headings
+}
+
+func apiErrorStatus(err error) int {
+	if errors.Is(err, git.ErrInvalidRef) {
+		return http.StatusBadRequest
+	}
+
+	if errors.Is(err, app.ErrFileNotFound) || errors.Is(err, fs.ErrNotExist) || errors.Is(err, git.ErrUnknownRef) {
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	slog.Debug("API error", "status", status, "error", err)
+
+	writeAPIJSON(w, status, apiErrorJSON{Error: err.Error()})
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("Error while JSON marshal", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
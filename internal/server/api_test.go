@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func serveAPI(t *testing.T, handler http.Handler, method, target, body string, v any) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/json")
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), v))
+
+	return rec.Code
+}
+
+func TestAPIInfo(t *testing.T) {
+	param := newDirectoryModeParam(t)
+	param.Version = "v1.2.3"
+	handler := apiHandler("", param)
+
+	var info apiInfoJSON
+
+	status := serveAPI(t, handler, http.MethodGet, "/__/api/v1/info", "", &info)
+	assert.Equal(t, status, http.StatusOK)
+	assert.DeepEqual(t, info, apiInfoJSON{
+		Version:         "v1.2.3",
+		ProtocolVersion: protocolVersion,
+		Root:            testDataDir,
+		DirectoryMode:   true,
+		Mode:            "gfm",
+		ColorMode:       "auto",
+	})
+}
+
+func TestAPIFiles(t *testing.T) {
+	handler := apiHandler("", newDirectoryModeParam(t))
+
+	var files apiFilesJSON
+
+	status := serveAPI(t, handler, http.MethodGet, "/__/api/v1/files", "", &files)
+	assert.Equal(t, status, http.StatusOK)
+	assert.Equal(t, files.Path, ".")
+
+	byName := map[string]apiFileJSON{}
+	for _, file := range files.Files {
+		byName[file.Name] = file
+	}
+
+	assert.True(t, byName["subdir"].IsDir)
+	assert.False(t, byName["subdir"].IsText)
+	assert.True(t, byName["gfm-alerts.md"].IsText)
+	assert.True(t, byName["gfm-alerts.md"].Size > 0)
+	assert.True(t, byName["gfm-alerts.md"].ModTime != "")
+
+	status = serveAPI(t, handler, http.MethodGet, "/__/api/v1/files?path=subdir", "", &files)
+	assert.Equal(t, status, http.StatusOK)
+	assert.Equal(t, len(files.Files), 1)
+	assert.Equal(t, files.Files[0].Path, "subdir/README.md")
+
+	var apiErr apiErrorJSON
+
+	status = serveAPI(t, handler, http.MethodGet, "/__/api/v1/files?path=../", "", &apiErr)
+	assert.Equal(t, status, http.StatusNotFound)
+
+	status = serveAPI(t, handler, http.MethodGet, "/__/api/v1/files?path=missing", "", &apiErr)
+	assert.Equal(t, status, http.StatusNotFound)
+}
+
+func TestAPIHeadings(t *testing.T) {
+	handler := apiHandler("", newDirectoryModeParam(t))
+
+	var headings apiHeadingsJSON
+
+	status := serveAPI(t, handler, http.MethodGet, "/__/api/v1/headings?path=subdir", "", &headings)
+	assert.Equal(t, status, http.StatusOK)
+	assert.Equal(t, headings.Headings[0], apiHeadingJSON{Level: 1, ID: "subdirectory-readme", Text: "Subdirectory README"})
+
+	// single file mode
+	handler = apiHandler(filepath.Join(testDataDir, "gfm-alerts.md"), &Param{})
+
+	status = serveAPI(t, handler, http.MethodGet, "/__/api/v1/headings", "", &headings)
+	assert.Equal(t, status, http.StatusOK)
+	assert.Equal(t, headings.Headings[0].Text, "Alerts")
+
+	var apiErr apiErrorJSON
+
+	status = serveAPI(t, handler, http.MethodGet, "/__/api/v1/headings?path=missing.md", "", &apiErr)
+	assert.Equal(t, status, http.StatusNotFound)
+}
+
+func TestAPIRender(t *testing.T) {
+	handler := apiHandler("", &Param{InteractiveTasks: true})
+
+	var rendered apiRenderJSON
+
+	status := serveAPI(t, handler, http.MethodPost, "/__/api/v1/render", `{"markdown": "# Title\n\n- [ ] task\n\n~~gone~~"}`, &rendered)
+	assert.Equal(t, status, http.StatusOK)
+	assert.True(t, strings.Contains(rendered.HTML, "<del>gone</del>"))
+	assert.False(t, strings.Contains(rendered.HTML, "data-task-line"))
+	assert.DeepEqual(t, rendered.Headings, []apiHeadingJSON{{Level: 1, ID: "title", Text: "Title"}})
+
+	status = serveAPI(t, handler, http.MethodPost, "/__/api/v1/render", `{"markdown": "~~gone~~", "mode": "markdown"}`, &rendered)
+	assert.Equal(t, status, http.StatusOK)
+	assert.False(t, strings.Contains(rendered.HTML, "<del>"))
+
+	var apiErr apiErrorJSON
+
+	status = serveAPI(t, handler, http.MethodPost, "/__/api/v1/render", `{"mode": "rst"}`, &apiErr)
+	assert.Equal(t, status, http.StatusBadRequest)
+	assert.Equal(t, apiErr.Error, errAPIInvalidMode.Error())
+
+	status = serveAPI(t, handler, http.MethodPost, "/__/api/v1/render", `not json`, &apiErr)
+	assert.Equal(t, status, http.StatusBadRequest)
+
+	status = serveAPI(t, handler, http.MethodGet, "/__/api/v1/unknown", "", &apiErr)
+	assert.Equal(t, status, http.StatusNotFound)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const tokenParam = "token"
+
+// resolveToken returns the token clients must present, or "" when access is
+// not restricted. Unless disabled, a random token is generated when the
+// server is reachable from other hosts.
+func (param *Param) resolveToken(addr net.Addr) string {
+	if param.DisableToken {
+		if !isLoopback(addr) {
+			slog.Warn(
+				"Token authentication is disabled while listening on a non-loopback address, "+
+					"anyone who can reach this host can read every file served by the preview",
+				"address", addr,
+			)
+		}
+
+		return ""
+	}
+
+	if param.Token != "" || isLoopback(addr) {
+		return param.Token
+	}
+
+	return rand.Text()
+}
+
+// isLoopback reports whether addr is only reachable from this machine. Unix
+// sockets are protected by their file permissions instead.
+func isLoopback(addr net.Addr) bool {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		return addr.IP.IsLoopback()
+	case *net.UnixAddr:
+		return true
+	default:
+		return false
+	}
+}
+
+// tokenCookieName includes the port, since cookies are shared between every
+// port of a host and so between concurrently running previews.
+func tokenCookieName(addr net.Addr) string {
+	name := "gfm-preview-token"
+
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		name += "-" + strconv.Itoa(tcpAddr.Port)
+	}
+
+	return name
+}
+
+// authHandler only lets requests through if they present token, either as
+// a "token" query parameter, a cookie or a bearer Authorization header. A
+// valid query parameter sets the cookie, so later requests (including the
+// live reload connection) do not need it.
+func authHandler(token, cookieName string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		if validToken(query.Get(tokenParam), token) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     cookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteStrictMode,
+			})
+
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				// keep the token out of the address bar and history
+				query.Del(tokenParam)
+
+				u := *r.URL
+				u.RawQuery = query.Encode()
+
+				http.Redirect(w, r, u.RequestURI(), http.StatusFound)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if cookie, err := r.Cookie(cookieName); err == nil && validToken(cookie.Value, token) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && validToken(bearer, token) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		slog.Debug("Unauthorized request", "remote_addr", r.RemoteAddr, "url", r.URL.Path)
+		http.Error(w, "Unauthorized: open the URL including the token printed by gh-gfm-preview", http.StatusUnauthorized)
+	})
+}
+
+func validToken(candidate, token string) bool {
+	return candidate != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestResolveToken(t *testing.T) {
+	loopback := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 3333}
+	public := &net.TCPAddr{IP: net.IPv4zero, Port: 3333}
+
+	assert.Equal(t, (&Param{}).resolveToken(loopback), "")
+	assert.Equal(t, (&Param{Token: "secret"}).resolveToken(loopback), "secret")
+	assert.Equal(t, (&Param{Token: "secret"}).resolveToken(public), "secret")
+	assert.Equal(t, (&Param{Token: "secret", DisableToken: true}).resolveToken(public), "")
+	assert.Equal(t, (&Param{DisableToken: true}).resolveToken(public), "")
+
+	generated := (&Param{}).resolveToken(public)
+	assert.True(t, len(generated) >= 20)
+	assert.True(t, generated != (&Param{}).resolveToken(public))
+}
+
+func TestTokenCookieName(t *testing.T) {
+	assert.Equal(t, tokenCookieName(&net.TCPAddr{IP: net.IPv4zero, Port: 4000}), "gfm-preview-token-4000")
+}
+
+func TestAuthHandler(t *testing.T) {
+	const token = "secret"
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := authHandler(token, "gfm-preview-token", next)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		cookie     string
+		header     string
+		wantStatus int
+		wantCookie bool
+		wantTarget string
+	}{
+		{"No token", http.MethodGet, "/", "", "", http.StatusUnauthorized, false, ""},
+		{"Wrong token", http.MethodGet, "/?token=nope", "", "", http.StatusUnauthorized, false, ""},
+		{"Wrong cookie", http.MethodGet, "/__/md", "nope", "", http.StatusUnauthorized, false, ""},
+		{"Query token redirects", http.MethodGet, "/docs/README.md?token=secret&ref=main", "", "", http.StatusFound, true, "/docs/README.md?ref=main"},
+		{"Query token on POST", http.MethodPost, "/__/task?token=secret", "", "", http.StatusOK, true, ""},
+		{"Cookie", http.MethodGet, "/ws", token, "", http.StatusOK, false, ""},
+		{"Bearer", http.MethodGet, "/__/md", "", "Bearer secret", http.StatusOK, false, ""},
+		{"Wrong bearer", http.MethodGet, "/__/md", "", "Bearer nope", http.StatusUnauthorized, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "gfm-preview-token", Value: tt.cookie})
+			}
+
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, res.StatusCode, tt.wantStatus)
+			assert.Equal(t, len(res.Cookies()) == 1, tt.wantCookie)
+
+			if tt.wantTarget != "" {
+				assert.Equal(t, rec.Header().Get("Location"), tt.wantTarget)
+			}
+		})
+	}
+}
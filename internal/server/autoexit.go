@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// exitWhenUnused calls stop once no client has been connected for
+// afterDisconnect since the last one left, or when no client connected at all
+// within idleTimeout. A zero duration disables the respective check.
+func exitWhenUnused(ctx context.Context, counts <-chan int, afterDisconnect, idleTimeout time.Duration, stop context.CancelFunc) {
+	var (
+		timer   *time.Timer
+		timeout <-chan time.Time
+		reason  string
+	)
+
+	resetTimer := func(d time.Duration, why string) {
+		if timer != nil {
+			timer.Stop()
+		}
+
+		timer = time.NewTimer(d)
+		timeout = timer.C
+		reason = why
+	}
+
+	if idleTimeout > 0 {
+		resetTimer(idleTimeout, "no client connected")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case count := <-counts:
+			switch {
+			case count > 0:
+				if timer != nil {
+					timer.Stop()
+				}
+
+				timeout = nil
+			case afterDisconnect > 0:
+				resetTimer(afterDisconnect, "last client disconnected")
+			}
+		case <-timeout:
+			slog.Info("Exiting since the preview is not being used", "reason", reason)
+			stop()
+
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func runExitWhenUnused(t *testing.T, counts <-chan int, afterDisconnect, idleTimeout time.Duration) context.Context {
+	t.Helper()
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+
+	go exitWhenUnused(ctx, counts, afterDisconnect, idleTimeout, stop)
+
+	return ctx
+}
+
+func isStopped(ctx context.Context, wait time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-time.After(wait):
+		return false
+	}
+}
+
+func TestExitWhenUnusedIdleTimeout(t *testing.T) {
+	ctx := runExitWhenUnused(t, make(chan int), 0, 20*time.Millisecond)
+
+	assert.True(t, isStopped(ctx, time.Second))
+}
+
+func TestExitWhenUnusedIdleTimeoutCancelledByClient(t *testing.T) {
+	counts := make(chan int)
+	ctx := runExitWhenUnused(t, counts, 0, 50*time.Millisecond)
+
+	counts <- 1
+
+	assert.False(t, isStopped(ctx, 100*time.Millisecond))
+
+	// without afterDisconnect, disconnects are ignored
+	counts <- 0
+
+	assert.False(t, isStopped(ctx, 100*time.Millisecond))
+}
+
+func TestExitWhenUnusedAfterDisconnect(t *testing.T) {
+	counts := make(chan int)
+	ctx := runExitWhenUnused(t, counts, 50*time.Millisecond, 0)
+
+	// nothing happens until a client connected and left
+	assert.False(t, isStopped(ctx, 100*time.Millisecond))
+
+	counts <- 1
+	counts <- 0
+	// reconnecting within the grace period, e.g. on page reload
+	counts <- 1
+
+	assert.False(t, isStopped(ctx, 100*time.Millisecond))
+
+	counts <- 0
+
+	assert.True(t, isStopped(ctx, time.Second))
+}
+
+func TestBrokerNotifiesClientCount(t *testing.T) {
+	broker := newBroker()
+
+	broker.notifyCount(1)
+	broker.notifyCount(2)
+
+	// only the latest count is kept
+	assert.Equal(t, <-broker.counts, 2)
+}
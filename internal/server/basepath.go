@@ -0,0 +1,48 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+var errInvalidBasePath = errors.New("base path must not contain a query, fragment or \"..\"")
+
+// normalizeBasePath returns p as "/prefix", without trailing slash, or "" when
+// the server is mounted at the root.
+func normalizeBasePath(p string) (string, error) {
+	if strings.ContainsAny(p, "?#") || strings.Contains(p, "..") {
+		return "", fmt.Errorf("%w: %q", errInvalidBasePath, p)
+	}
+
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return "", nil
+	}
+
+	return p, nil
+}
+
+// basePathHandler serves next under basePath, as if it was mounted at the
+// root, for use behind reverse proxies that do not strip the prefix.
+func basePathHandler(basePath string, next http.Handler) http.Handler {
+	stripped := http.StripPrefix(basePath, next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == basePath:
+			target := basePath + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, basePath+"/"):
+			stripped.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestNormalizeBasePath(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"/", "", false},
+		{"preview", "/preview", false},
+		{"/preview/alice/", "/preview/alice", false},
+		{"//preview//alice", "/preview/alice", false},
+		{"/preview/../other", "", true},
+		{"/preview?x=1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := normalizeBasePath(tt.input)
+			assert.Equal(t, err != nil, tt.wantErr)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestBasePathHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	})
+	handler := basePathHandler("/preview/alice", next)
+
+	tests := []struct {
+		target       string
+		wantStatus   int
+		wantBody     string
+		wantLocation string
+	}{
+		{"/preview/alice/", http.StatusOK, "/", ""},
+		{"/preview/alice/docs/README.md", http.StatusOK, "/docs/README.md", ""},
+		{"/preview/alice/__/md?path=README.md", http.StatusOK, "/__/md", ""},
+		{"/preview/alice?token=secret", http.StatusMovedPermanently, "", "/preview/alice/?token=secret"},
+		{"/preview/alicex/", http.StatusNotFound, "", ""},
+		{"/static/script.js", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, tt.wantStatus)
+
+			if tt.wantBody != "" {
+				assert.Equal(t, rec.Body.String(), tt.wantBody)
+			}
+
+			if tt.wantLocation != "" {
+				assert.Equal(t, rec.Header().Get("Location"), tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateWithBasePath(t *testing.T) {
+	param := &Param{BasePath: "/preview/alice", CodeDarkStyle: "monokai"}
+	templateParam := sampleTemplateParam()
+	templateParam.BreadcrumbItems = []BreadcrumbItem{{Name: "docs", Path: "docs"}}
+
+	rec := httptest.NewRecorder()
+	renderTemplate(rec, param, templateParam)
+
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, `src="/preview/alice/static/script.js"`))
+	assert.True(t, strings.Contains(body, `href="/preview/alice/__/chroma/monokai.css"`))
+	assert.True(t, strings.Contains(body, `href="/preview/alice/docs/"`))
+	assert.True(t, strings.Contains(body, `href="/preview/alice/docs/README.md"`))
+	assert.False(t, strings.Contains(body, `src="/static/`))
+	assert.False(t, strings.Contains(body, `href="/static/`))
+}
@@ -1,40 +1,100 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"maps"
+	"strconv"
 	"sync"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
 )
 
+// protocolVersion is the version of the messages exchanged with clients,
+// announced in helloMessage. Clients served by a different version must do a
+// full page reload, since their script may not understand this server.
+const protocolVersion = 1
+
+// helloMessage is the first message sent to every client.
+var helloMessage = []byte("hello " + strconv.Itoa(protocolVersion))
+
+// shutdownMessage tells clients that the server is stopping and they should
+// not expect further updates.
+var shutdownMessage = []byte("shutdown")
+
 type wsMessage struct {
 	message []byte
 	err     error
+	// close the connection after the message is sent.
+	close bool
+}
+
+// brokerClient is a live reload connection, either a WebSocket or a
+// Server-Sent Events stream.
+type brokerClient interface {
+	// queue returns the channel messages for the client are sent to. The
+	// broker closes it once the client is unregistered.
+	queue() chan wsMessage
+	remoteAddr() string
 }
 
 // wsBroker handles registering/unregistering clients and broadcasting messages to them.
 type wsBroker struct {
 	// Registered clients.
-	clients map[*wsClient]bool
+	clients map[brokerClient]bool
 	// Register requests from the clients.
-	register chan *wsClient
+	register chan brokerClient
 	// Unregister requests from clients.
-	unregister chan *wsClient
+	unregister chan brokerClient
 	// Broadcast messages to all clients.
 	broadcast chan wsMessage
 	// Protect clients map during iteration if needed elsewhere.
 	mu sync.RWMutex
+	// Shutdown requests, closed once the last client is unregistered.
+	drain chan chan struct{}
+	// Number of clients after each change, only the latest one is kept.
+	counts chan int
+}
+
+// startBroker returns a running broker that forwards watcher reload signals
+// to its clients until the watcher stops.
+func startBroker(watcher *watcher.Watcher) *wsBroker {
+	broker := newBroker()
+	go broker.run()
+
+	go func() {
+		for {
+			select {
+			case message := <-watcher.MessageCh:
+				broker.broadcast <- wsMessage{message: message}
+			case err := <-watcher.ErrorCh:
+				broker.broadcast <- wsMessage{err: err}
+			case <-watcher.DoneCh:
+				return
+			}
+		}
+	}()
+
+	go watcher.Watch()
+
+	return broker
 }
 
 func newBroker() *wsBroker {
 	return &wsBroker{
-		clients:    make(map[*wsClient]bool),
-		register:   make(chan *wsClient),
-		unregister: make(chan *wsClient),
+		clients:    make(map[brokerClient]bool),
+		register:   make(chan brokerClient),
+		unregister: make(chan brokerClient),
 		broadcast:  make(chan wsMessage, 1),
+		drain:      make(chan chan struct{}),
+		counts:     make(chan int, 1),
 	}
 }
 
 func (b *wsBroker) run() {
+	var drained chan struct{}
+
 	for {
 		select {
 		case c := <-b.register:
@@ -42,43 +102,118 @@ func (b *wsBroker) run() {
 
 			b.mu.Lock()
 			b.clients[c] = true
+			count := len(b.clients)
 			b.mu.Unlock()
 
+			b.notifyCount(count)
+
+			if drained != nil {
+				// connected while shutting down, so it missed the broadcast
+				c.queue() <- wsMessage{message: shutdownMessage, close: true}
+			}
+
 		case c := <-b.unregister:
 			slog.Debug("Unregistering client from broker", "remote_addr", c.remoteAddr())
 
 			b.mu.Lock()
 
-			if _, ok := b.clients[c]; ok {
+			_, ok := b.clients[c]
+			if ok {
 				delete(b.clients, c)
-				close(c.send)
+				close(c.queue())
 			}
 
+			count := len(b.clients)
 			b.mu.Unlock()
 
-		case msg := <-b.broadcast:
-			b.mu.RLock()
-			clients := maps.Keys(b.clients)
-			b.mu.RUnlock()
-
-			for c := range clients {
-				slog.Debug(
-					"Sending message to client",
-					"remote_addr", c.conn.UnderlyingConn().RemoteAddr(),
-					"message", string(msg.message),
-					"error", msg.err,
-				)
-
-				select {
-				case c.send <- msg:
-					// ok
-				default:
-					// client is stuck → unregister it safely
-					go func(c *wsClient) {
-						b.unregister <- c
-					}(c)
-				}
+			if ok {
+				b.notifyCount(count)
 			}
+
+			drained = b.closeIfDrained(drained)
+
+		case done := <-b.drain:
+			drained = done
+			b.send(wsMessage{message: shutdownMessage, close: true})
+			drained = b.closeIfDrained(drained)
+
+		case msg := <-b.broadcast:
+			b.send(msg)
 		}
 	}
 }
+
+func (b *wsBroker) send(msg wsMessage) {
+	b.mu.RLock()
+	clients := maps.Keys(b.clients)
+	b.mu.RUnlock()
+
+	for c := range clients {
+		slog.Debug(
+			"Sending message to client",
+			"remote_addr", c.remoteAddr(),
+			"message", string(msg.message),
+			"error", msg.err,
+		)
+
+		select {
+		case c.queue() <- msg:
+			// ok
+		default:
+			// client is stuck → unregister it safely
+			go func(c brokerClient) {
+				b.unregister <- c
+			}(c)
+		}
+	}
+}
+
+// notifyCount publishes the current number of clients, replacing a previous
+// value nobody received yet so the broker never blocks on it.
+func (b *wsBroker) notifyCount(count int) {
+	select {
+	case <-b.counts:
+	default:
+	}
+
+	b.counts <- count
+}
+
+// closeIfDrained closes drained once all clients are gone, returning nil in
+// that case so it is only closed once.
+func (b *wsBroker) closeIfDrained(drained chan struct{}) chan struct{} {
+	if drained == nil {
+		return nil
+	}
+
+	b.mu.RLock()
+	empty := len(b.clients) == 0
+	b.mu.RUnlock()
+
+	if !empty {
+		return drained
+	}
+
+	close(drained)
+
+	return nil
+}
+
+// shutdown notifies all clients that the server is stopping, closes their
+// connections and waits until they are gone or ctx is done.
+func (b *wsBroker) shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+
+	select {
+	case b.drain <- done:
+	case <-ctx.Done():
+		return fmt.Errorf("notify clients about shutdown: %w", ctx.Err())
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait for clients to close: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+const (
+	defaultLightCodeStyle = "github"
+	defaultDarkCodeStyle  = "github-dark"
+)
+
+var errUnknownCodeStyle = errors.New("unknown code highlighting style")
+
+// chromaStyle returns the registered chroma style called name.
+func chromaStyle(name string) (*chroma.Style, error) {
+	style, ok := styles.Registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: %s (available: %s)",
+			errUnknownCodeStyle, name, strings.Join(styles.Names(), ", "),
+		)
+	}
+
+	return style, nil
+}
+
+// chromaCSSURL returns the stylesheet URL for the chroma style called name.
+// The default GitHub styles are pre-generated by _tools/generate-assets.go,
+// any other style is generated at runtime by chromaCSSHandler.
+func chromaCSSURL(name string) string {
+	switch strings.ToLower(name) {
+	case "", defaultLightCodeStyle:
+		return "/static/generated/chroma-github-light.css"
+	case defaultDarkCodeStyle:
+		return "/static/generated/chroma-github-dark.css"
+	}
+
+	return "/__/chroma/" + url.PathEscape(strings.ToLower(name)) + ".css"
+}
+
+// chromaCSSHandler generates the CSS for the chroma style in the request path,
+// e.g. /__/chroma/monokai.css.
+func chromaCSSHandler() http.Handler {
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := strings.CutSuffix(path.Base(r.URL.Path), ".css")
+		if !ok {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		style, err := chromaStyle(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+
+		err = formatter.WriteCSS(w, style)
+		if err != nil {
+			slog.Error("Error while generating chroma CSS", "style", name, "error", err)
+		}
+	})
+}
+
+func (param *Param) lightCodeStyleURL() string {
+	return chromaCSSURL(param.CodeLightStyle)
+}
+
+func (param *Param) darkCodeStyleURL() string {
+	if param.CodeDarkStyle == "" {
+		return chromaCSSURL(defaultDarkCodeStyle)
+	}
+
+	return chromaCSSURL(param.CodeDarkStyle)
+}
+
+func (param *Param) validateCodeStyles() error {
+	for _, name := range []string{param.CodeLightStyle, param.CodeDarkStyle} {
+		if name == "" {
+			continue
+		}
+
+		if _, err := chromaStyle(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
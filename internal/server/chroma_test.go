@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestChromaCSSURL(t *testing.T) {
+	assert.Equal(t, chromaCSSURL(""), "/static/generated/chroma-github-light.css")
+	assert.Equal(t, chromaCSSURL("github"), "/static/generated/chroma-github-light.css")
+	assert.Equal(t, chromaCSSURL("GitHub-Dark"), "/static/generated/chroma-github-dark.css")
+	assert.Equal(t, chromaCSSURL("monokai"), "/__/chroma/monokai.css")
+
+	param := &Param{}
+	assert.Equal(t, param.lightCodeStyleURL(), "/static/generated/chroma-github-light.css")
+	assert.Equal(t, param.darkCodeStyleURL(), "/static/generated/chroma-github-dark.css")
+
+	param = &Param{CodeLightStyle: "xcode", CodeDarkStyle: "dracula"}
+	assert.Equal(t, param.lightCodeStyleURL(), "/__/chroma/xcode.css")
+	assert.Equal(t, param.darkCodeStyleURL(), "/__/chroma/dracula.css")
+}
+
+func TestChromaCSSHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{"Existing style", "/__/chroma/monokai.css", http.StatusOK},
+		{"Case insensitive", "/__/chroma/Monokai.css", http.StatusOK},
+		{"Unknown style", "/__/chroma/does-not-exist.css", http.StatusNotFound},
+		{"Not CSS", "/__/chroma/monokai", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			chromaCSSHandler().ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, tt.wantStatus)
+
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, rec.Header().Get("Content-Type"), "text/css; charset=utf-8")
+				assert.True(t, strings.Contains(rec.Body.String(), ".chroma .hl {"))
+			}
+		})
+	}
+}
+
+func TestValidateCodeStyles(t *testing.T) {
+	assert.Nil(t, (&Param{}).validateCodeStyles())
+	assert.Nil(t, (&Param{CodeLightStyle: "github", CodeDarkStyle: "monokai"}).validateCodeStyles())
+	assert.NotNil(t, (&Param{CodeDarkStyle: "nope"}).validateCodeStyles())
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/git"
+)
+
+// loadCustomTemplate parses a user provided replacement for template.html,
+// validating that it only references fields available in TemplateParam.
+func loadCustomTemplate(filename string) (*template.Template, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("custom template read error: %w", err)
+	}
+
+	t, err := template.New(filepath.Base(filename)).Funcs(templateFuncs).Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("custom template parse error: %w", err)
+	}
+
+	err = t.Execute(io.Discard, sampleTemplateParam())
+	if err != nil {
+		return nil, fmt.Errorf("custom template validation error: %w", err)
+	}
+
+	return t, nil
+}
+
+// sampleTemplateParam returns a TemplateParam with every field set, so
+// executing a template against it exercises all of its branches.
+func sampleTemplateParam() TemplateParam {
+	return TemplateParam{
+		Title:             "README.md",
+		Body:              "<h1>Title</h1>",
+		HeadingsHTML:      `<a href="#title">Title</a>`,
+		HasHeadings:       true,
+		InteractiveTasks:  true,
+		Host:              "localhost:3333",
+		Reload:            true,
+		ProtocolVersion:   protocolVersion,
+		Mode:              autoMode.String(),
+		CustomCSS:         true,
+		CodeLightStyleURL: chromaCSSURL(defaultLightCodeStyle),
+		CodeDarkStyleURL:  chromaCSSURL(defaultDarkCodeStyle),
+		ShowBrowseButton:  true,
+		IsDirectoryMode:   true,
+		HasReadme:         true,
+		DirectoryTitle:    "docs",
+		Files:             []FileInfo{{Name: "README.md", Path: "docs/README.md", Depth: 1}},
+		FileTree:          []FileTreeItem{{Name: "README.md", Path: "docs/README.md", GitStatus: git.StatusModified}},
+		CurrentPath:       "docs",
+		BreadcrumbItems:   []BreadcrumbItem{{Name: "docs", Path: "docs"}},
+		Ref:               "main",
+		HasGitStatus:      true,
+		ChangedOnly:       true,
+		LandingFiles: []LandingFileItem{{
+			FileTreeItem: FileTreeItem{Name: "README.md", Path: "README.md"},
+			Commit:       LandingCommit{Subject: "Add README", Hash: "0123456789abcdef0123456789abcdef01234567", Date: "Jan 2, 2006", DateTime: "2006-01-02T15:04:05Z"},
+		}},
+		LandingCommit: LandingCommit{Subject: "Add README", Hash: "0123456789abcdef0123456789abcdef01234567", Date: "Jan 2, 2006", DateTime: "2006-01-02T15:04:05Z"},
+		LandingTabs:   []LandingTab{{Name: "README", Selected: true}, {Tab: "license", Name: "License"}},
+		DocumentPath:  "LICENSE",
+	}
+}
+
+// customCSSHandler serves the user provided stylesheet, read on every request
+// so edits are picked up on reload.
+func customCSSHandler(filename string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := os.Open(filename)
+		if err != nil {
+			slog.Error("Error while opening custom CSS", "error", err)
+			http.Error(w, err.Error(), http.StatusNotFound)
+
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		http.ServeContent(w, r, filepath.Base(filename), info.ModTime(), f)
+	})
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
+)
+
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(filename, []byte(content), 0o600)
+	assert.Nil(t, err)
+
+	return filename
+}
+
+func TestLoadCustomTemplate(t *testing.T) {
+	// the embedded template is a valid custom template
+	_, err := loadCustomTemplate(writeTempFile(t, "template.html", htmlTemplate))
+	assert.Nil(t, err)
+
+	_, err = loadCustomTemplate(writeTempFile(t, "custom.html", `<h1>Company</h1>{{ .Body }}{{ range .FileTree }}{{ urlPathEscape .Path }}{{ end }}`))
+	assert.Nil(t, err)
+
+	_, err = loadCustomTemplate(writeTempFile(t, "unknown-field.html", `{{ .Company }}`))
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "validation error"))
+
+	_, err = loadCustomTemplate(writeTempFile(t, "invalid.html", `{{ if }}`))
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "parse error"))
+
+	_, err = loadCustomTemplate(filepath.Join(t.TempDir(), "missing.html"))
+	assert.NotNil(t, err)
+}
+
+func TestHandlerUsesCustomTemplateAndCSS(t *testing.T) {
+	filename := "../../testdata/markdown-demo.md"
+	dir := filepath.Dir(filename)
+
+	customTemplate, err := loadCustomTemplate(writeTempFile(t, "custom.html",
+		`<header>Company</header>{{ if .CustomCSS }}<link href="/__/custom.css">{{ end }}<main>{{ .Body }}</main>`))
+	assert.Nil(t, err)
+
+	param := &Param{CustomCSS: "custom.css", template: customTemplate}
+
+	w, err := watcher.Init(dir)
+	assert.Nil(t, err)
+
+	defer w.Close()
+
+	ts := httptest.NewServer(handler(filename, param, http.FileServer(http.Dir(dir)), w))
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL)
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+
+	assert.True(t, strings.HasPrefix(string(body), `<header>Company</header><link href="/__/custom.css"><main>`))
+}
+
+func TestCustomCSSHandler(t *testing.T) {
+	filename := writeTempFile(t, "custom.css", ".company { color: red; }")
+
+	req := httptest.NewRequest(http.MethodGet, "/__/custom.css", nil)
+	rec := httptest.NewRecorder()
+
+	customCSSHandler(filename).ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "text/css; charset=utf-8")
+	assert.Equal(t, rec.Body.String(), ".company { color: red; }")
+
+	// edits are served without restarting
+	err := os.WriteFile(filename, []byte(".company { color: blue; }"), 0o600)
+	assert.Nil(t, err)
+
+	rec = httptest.NewRecorder()
+	customCSSHandler(filename).ServeHTTP(rec, req)
+	assert.Equal(t, rec.Body.String(), ".company { color: blue; }")
+
+	rec = httptest.NewRecorder()
+	customCSSHandler(filepath.Join(t.TempDir(), "missing.css")).ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
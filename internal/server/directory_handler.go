@@ -1,8 +1,10 @@
 package server
 
 import (
+	"cmp"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
@@ -20,7 +22,6 @@ func handleDirectoryMode(w http.ResponseWriter, r *http.Request, param *Param, w
 	urlPath = strings.TrimSuffix(urlPath, "/")
 
 	extensions := app.ParseExtensions(param.DirectoryListingShowExtensions)
-	textExtensions := app.ParseExtensions(param.DirectoryListingTextExtensions)
 
 	currentURLPath := resolveDirectoryPath(urlPath)
 
@@ -46,7 +47,7 @@ func handleDirectoryMode(w http.ResponseWriter, r *http.Request, param *Param, w
 	isFile := !info.IsDir()
 
 	if isFile {
-		handleFileRequest(w, r, param, watcher, currentURLPath, extensions, textExtensions, info)
+		handleFileRequest(w, r, param, watcher, currentURLPath, extensions, info)
 
 		return
 	}
@@ -55,11 +56,12 @@ func handleDirectoryMode(w http.ResponseWriter, r *http.Request, param *Param, w
 }
 
 func statDirectoryTarget(param *Param, currentURLPath string) (os.FileInfo, error) {
-	if param.DirectoryRoot == nil {
-		return nil, errNoDirectoryRoot
+	fsys, err := param.directoryFS()
+	if err != nil {
+		return nil, err
 	}
 
-	info, err := param.DirectoryRoot.Stat(rootRelativePath(currentURLPath))
+	info, err := fs.Stat(fsys, rootRelativePath(currentURLPath))
 	if err != nil {
 		return nil, fmt.Errorf("directory target root stat error: %w", err)
 	}
@@ -78,7 +80,6 @@ func handleFileRequest(
 	watcher *watcher.Watcher,
 	currentURLPath string,
 	extensions []string,
-	textExtensions []string,
 	info os.FileInfo,
 ) {
 	fileDirURLPath := getParentPath(currentURLPath)
@@ -88,8 +89,10 @@ func handleFileRequest(
 		slog.Debug("Add directory to watcher error", "error", err)
 	}
 
-	if !app.IsTextFile(currentURLPath, textExtensions) {
-		serveRootFile(w, r, param, currentURLPath, info)
+	// Files embedded in pages or explicitly requested raw are served as is,
+	// navigating to them shows a viewer page instead
+	if (r.URL.Query().Has("raw") || !isDocumentRequest(r)) && isMediaTarget(param, currentURLPath) {
+		serveRawFile(w, r, param, currentURLPath, info)
 
 		return
 	}
@@ -97,6 +100,15 @@ func handleFileRequest(
 	renderFileTemplate(w, r, param, currentURLPath, fileDirURLPath, extensions)
 }
 
+func isMediaTarget(param *Param, currentURLPath string) bool {
+	fsys, err := param.directoryFS()
+	if err != nil {
+		return false
+	}
+
+	return param.isMediaFile(fsys, currentURLPath)
+}
+
 func renderFileTemplate(w http.ResponseWriter, r *http.Request, param *Param, currentURLPath, fileDirURLPath string, extensions []string) {
 	markdownView, title, err := mdResponseFromRoot(w, currentURLPath, param)
 	if err != nil {
@@ -110,6 +122,7 @@ func renderFileTemplate(w http.ResponseWriter, r *http.Request, param *Param, cu
 		Body:             template.HTML(markdownView.HTML),         //nolint:gosec // G203: rendered Markdown is intentionally raw HTML
 		HeadingsHTML:     template.HTML(markdownView.HeadingsHTML), //nolint:gosec // G203: rendered Markdown is intentionally raw HTML
 		HasHeadings:      markdownView.HasHeadings,
+		InteractiveTasks: param.interactiveTasks(),
 		Host:             r.Host,
 		Reload:           param.Reload,
 		Mode:             param.getMode().String(),
@@ -122,17 +135,17 @@ func renderFileTemplate(w http.ResponseWriter, r *http.Request, param *Param, cu
 		BreadcrumbItems:  generateBreadcrumbItems(getParentPath(currentURLPath), path.Base(currentURLPath), false),
 	}
 
-	files, dirs, err := app.ListDirectoryContentsFS(param.DirectoryRoot.FS(), rootRelativePath(fileDirURLPath), extensions)
+	files, dirs, err := listDirectoryContents(param, fileDirURLPath, extensions)
 	if err == nil {
 		dirURLPath := getParentPath(currentURLPath)
-		templateParam.FileTree = generateFileTree(files, dirs, dirURLPath)
+		templateParam.FileTree = fileTree(param.gitStatus(), files, dirs, dirURLPath)
 	}
 
-	renderTemplate(w, templateParam)
+	renderTemplate(w, param, templateParam)
 }
 
 func handleDirectoryRequest(w http.ResponseWriter, r *http.Request, param *Param, currentURLPath string, extensions []string) {
-	readme, readmeErr := app.FindReadmeFS(param.DirectoryRoot.FS(), rootRelativePath(currentURLPath))
+	readme, readmeErr := findReadme(param, currentURLPath)
 	viewMode := r.URL.Query().Get("view")
 
 	if viewMode == "index" || readmeErr != nil {
@@ -145,7 +158,7 @@ func handleDirectoryRequest(w http.ResponseWriter, r *http.Request, param *Param
 }
 
 func renderDirectoryListing(w http.ResponseWriter, r *http.Request, param *Param, currentURLPath string, extensions []string, hasReadme bool) {
-	files, dirs, err := app.ListDirectoryContentsFS(param.DirectoryRoot.FS(), rootRelativePath(currentURLPath), extensions)
+	files, dirs, err := listDirectoryContents(param, currentURLPath, extensions)
 	if err != nil {
 		slog.Error("Error listing directory", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -158,6 +171,26 @@ func renderDirectoryListing(w http.ResponseWriter, r *http.Request, param *Param
 		dirTitle = "Home"
 	}
 
+	status := param.gitStatus()
+	changedOnly := status != nil && r.URL.Query().Has("changed")
+
+	tree := fileTree(status, files, dirs, currentURLPath)
+
+	switch {
+	case changedOnly:
+		tree = changedFileTree(status, currentURLPath, extensions)
+	case param.Wiki && rootRelativePath(currentURLPath) == ".":
+		tree, err = listWikiPages(param, status)
+		if err != nil {
+			slog.Error("Error listing wiki pages", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		dirTitle = "Pages"
+	}
+
 	templateParam := TemplateParam{
 		Title:            "Browse Files",
 		Body:             "",
@@ -169,17 +202,33 @@ func renderDirectoryListing(w http.ResponseWriter, r *http.Request, param *Param
 		IsDirectoryIndex: true,
 		HasReadme:        hasReadme,
 		DirectoryTitle:   dirTitle,
-		FileTree:         generateFileTree(files, dirs, currentURLPath),
+		FileTree:         tree,
+		HasGitStatus:     status != nil,
+		ChangedOnly:      changedOnly,
 		CurrentPath:      currentURLPath,
 		ParentPath:       getParentPath(currentURLPath),
 		BreadcrumbItems:  generateBreadcrumbItems(getParentPath(currentURLPath), dirTitle, true),
 	}
 
-	renderTemplate(w, templateParam)
+	renderTemplate(w, param, templateParam)
 }
 
 func renderReadmeTemplate(w http.ResponseWriter, r *http.Request, param *Param, currentURLPath, readme string, extensions []string) {
-	markdownView, title, err := mdResponseFromRoot(w, readme, param)
+	var tree []FileTreeItem
+
+	files, dirs, err := listDirectoryContents(param, currentURLPath, extensions)
+	if err == nil {
+		tree = fileTree(param.gitStatus(), files, dirs, currentURLPath)
+	}
+
+	landing := &landingPage{}
+	if param.showsLanding(currentURLPath) {
+		landing = newLandingPage(r, param, tree)
+	}
+
+	document := cmp.Or(landing.document, readme)
+
+	markdownView, title, err := mdResponseFromRoot(w, document, param)
 	if err != nil {
 		slog.Error("Error while reading markdown", "error", err)
 
@@ -191,6 +240,7 @@ func renderReadmeTemplate(w http.ResponseWriter, r *http.Request, param *Param,
 		Body:             template.HTML(markdownView.HTML),         //nolint:gosec // G203: rendered Markdown is intentionally raw HTML
 		HeadingsHTML:     template.HTML(markdownView.HeadingsHTML), //nolint:gosec // G203: rendered Markdown is intentionally raw HTML
 		HasHeadings:      markdownView.HasHeadings,
+		InteractiveTasks: param.interactiveTasks(),
 		Host:             r.Host,
 		Reload:           param.Reload,
 		Mode:             param.getMode().String(),
@@ -198,17 +248,17 @@ func renderReadmeTemplate(w http.ResponseWriter, r *http.Request, param *Param,
 		IsDirectoryMode:  param.IsDirectoryMode,
 		IsDirectoryIndex: false,
 		HasReadme:        true,
+		FileTree:         tree,
 		CurrentPath:      currentURLPath,
 		ParentPath:       getParentPath(currentURLPath),
-		BreadcrumbItems:  generateBreadcrumbItems(currentURLPath, path.Base(readme), false),
-	}
-
-	files, dirs, err := app.ListDirectoryContentsFS(param.DirectoryRoot.FS(), rootRelativePath(currentURLPath), extensions)
-	if err == nil {
-		templateParam.FileTree = generateFileTree(files, dirs, currentURLPath)
+		BreadcrumbItems:  generateBreadcrumbItems(currentURLPath, path.Base(document), false),
+		LandingFiles:     landing.files,
+		LandingCommit:    landing.commit,
+		LandingTabs:      landing.tabs,
+		DocumentPath:     landing.document,
 	}
 
-	renderTemplate(w, templateParam)
+	renderTemplate(w, param, templateParam)
 }
 
 // generateFileTree creates FileTreeItem slice from files and directories.
@@ -356,12 +406,35 @@ func render404Error(w http.ResponseWriter, r *http.Request, param *Param, curren
 		BreadcrumbItems:  generateBreadcrumbItems(parentPath, path.Base(currentURLPath), false),
 	}
 
-	files, dirs, err := app.ListDirectoryContentsFS(param.DirectoryRoot.FS(), rootRelativePath(parentPath), extensions)
+	files, dirs, err := listDirectoryContents(param, parentPath, extensions)
 	if err == nil {
-		templateParam.FileTree = generateFileTree(files, dirs, parentPath)
+		templateParam.FileTree = fileTree(param.gitStatus(), files, dirs, parentPath)
+	}
+
+	renderTemplate(w, param, templateParam)
+}
+
+func listDirectoryContents(param *Param, dirURLPath string, extensions []string) ([]string, []string, error) {
+	fsys, err := param.directoryFS()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, dirs, err := app.ListDirectoryContentsFS(fsys, rootRelativePath(dirURLPath), extensions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list directory error: %w", err)
+	}
+
+	return files, dirs, nil
+}
+
+func findReadme(param *Param, dirURLPath string) (string, error) {
+	fsys, err := param.directoryFS()
+	if err != nil {
+		return "", err
 	}
 
-	renderTemplate(w, templateParam)
+	return param.findReadmeFS(fsys, rootRelativePath(dirURLPath))
 }
 
 func rootRelativePath(path string) string {
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/thiagokokada/gh-gfm-preview/internal/app"
 	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
 	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
 )
@@ -405,3 +406,210 @@ func TestDirectoryMarkdownViewMarksDirectoryModeForReload(t *testing.T) {
 	assert.True(t, strings.Contains(bodyStr, "isDirectoryMode:") && strings.Contains(bodyStr, "true"))
 	assert.True(t, strings.Contains(bodyStr, "isDirectoryIndex:") && strings.Contains(bodyStr, "false"))
 }
+
+func TestDirectoryTableFileRendering(t *testing.T) {
+	testDir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(testDir, "data.csv"), []byte("name,value\nfoo,1\n"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(testDir, "data.tsv"), []byte("name\tvalue\nbar\t2\n"), 0o600))
+
+	root, err := os.OpenRoot(testDir)
+	assert.Nil(t, err)
+
+	defer root.Close()
+
+	param := &Param{
+		DirectoryListing:               true,
+		DirectoryListingShowExtensions: ".md,.csv,.tsv",
+		DirectoryListingTextExtensions: ".md",
+		IsDirectoryMode:                true,
+		DirectoryPath:                  testDir,
+		DirectoryRoot:                  root,
+	}
+
+	watcher, err := watcher.Init(testDir)
+	assert.Nil(t, err)
+
+	defer watcher.Close()
+
+	ts := httptest.NewServer(handler("", param, http.FileServer(http.Dir(testDir)), watcher))
+	defer ts.Close()
+
+	for file, cell := range map[string]string{"data.csv": "<td>foo</td>", "data.tsv": "<td>bar</td>"} {
+		res, err := http.Get(ts.URL + "/" + file)
+		assert.Nil(t, err)
+
+		body, err := io.ReadAll(res.Body)
+		assert.Nil(t, err)
+		assert.Nil(t, res.Body.Close())
+
+		assert.Equal(t, res.StatusCode, http.StatusOK)
+		assert.True(t, strings.Contains(res.Header.Get("Content-Type"), "text/html"))
+		assert.True(t, strings.Contains(string(body), `<table class="table-file-data">`))
+		assert.True(t, strings.Contains(string(body), "<th>name</th>"))
+		assert.True(t, strings.Contains(string(body), cell))
+	}
+}
+
+func TestDirectoryNotebookRendering(t *testing.T) {
+	testDir := t.TempDir()
+	notebook := `{"cells": [
+		{"cell_type": "markdown", "source": ["# Analysis\n", "- [ ] todo"]},
+		{"cell_type": "code", "execution_count": 1, "source": "1 + 1", "outputs": [
+			{"output_type": "execute_result", "execution_count": 1, "data": {"text/plain": "2"}}
+		]}
+	], "metadata": {"language_info": {"name": "python"}}}`
+	assert.Nil(t, os.WriteFile(filepath.Join(testDir, "analysis.ipynb"), []byte(notebook), 0o600))
+
+	root, err := os.OpenRoot(testDir)
+	assert.Nil(t, err)
+
+	defer root.Close()
+
+	param := &Param{
+		DirectoryListing:               true,
+		DirectoryListingShowExtensions: ".ipynb",
+		DirectoryListingTextExtensions: ".md",
+		IsDirectoryMode:                true,
+		DirectoryPath:                  testDir,
+		DirectoryRoot:                  root,
+		InteractiveTasks:               true,
+	}
+
+	watcher, err := watcher.Init(testDir)
+	assert.Nil(t, err)
+
+	defer watcher.Close()
+
+	ts := httptest.NewServer(handler("", param, http.FileServer(http.Dir(testDir)), watcher))
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/analysis.ipynb")
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+
+	bodyStr := string(body)
+	assert.Equal(t, res.StatusCode, http.StatusOK)
+	assert.True(t, strings.Contains(bodyStr, `<h1 id="analysis">`))
+	assert.True(t, strings.Contains(bodyStr, `<div class="notebook-prompt">Out [1]:</div>`))
+	assert.True(t, strings.Contains(bodyStr, `href="#analysis"`))
+	assert.False(t, strings.Contains(bodyStr, "data-task-line"))
+}
+
+func TestDirectorySourceFileRendering(t *testing.T) {
+	testDir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(testDir, "main.go"), []byte("package main\n\n# not a heading\n"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(testDir, "notes.md"), []byte("# Notes\n"), 0o600))
+
+	root, err := os.OpenRoot(testDir)
+	assert.Nil(t, err)
+
+	defer root.Close()
+
+	param := &Param{
+		DirectoryListing:               true,
+		DirectoryListingShowExtensions: "*",
+		DirectoryListingTextExtensions: ".md,.go",
+		IsDirectoryMode:                true,
+		DirectoryPath:                  testDir,
+		DirectoryRoot:                  root,
+	}
+
+	view, title, err := renderMarkdownFromOpenedRoot("main.go", root, param)
+	assert.Nil(t, err)
+	assert.Equal(t, title, "main.go")
+	assert.True(t, strings.Contains(view.HTML, `<div class="source-file">`))
+	assert.True(t, strings.Contains(view.HTML, `id="L3"`))
+	assert.False(t, strings.Contains(view.HTML, "<h1"))
+	assert.False(t, view.HasHeadings)
+
+	view, _, err = renderMarkdownFromOpenedRoot("notes.md", root, param)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(view.HTML, `<h1 id="notes">`))
+}
+
+func TestDirectoryMarkupRendering(t *testing.T) {
+	testDir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(testDir, "README.org"), []byte("* Org heading\n"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(testDir, "guide.rst"), []byte("Title\n=====\n"), 0o600))
+
+	root, err := os.OpenRoot(testDir)
+	assert.Nil(t, err)
+
+	defer root.Close()
+
+	markupRenderers, err := app.NewMarkupRenderers([]string{".rst=gh-gfm-preview-missing-command"}, 0)
+	assert.Nil(t, err)
+
+	param := &Param{
+		IsDirectoryMode: true,
+		DirectoryPath:   testDir,
+		DirectoryRoot:   root,
+		markupRenderers: markupRenderers,
+	}
+
+	view, title, err := renderMarkdownFromOpenedRoot(".", root, param)
+	assert.Nil(t, err)
+	assert.Equal(t, title, "README.org")
+	assert.True(t, strings.Contains(view.HTML, "Org heading"))
+	assert.True(t, view.HasHeadings)
+
+	// Without a renderer command the source is shown
+	view, _, err = renderMarkdownFromOpenedRoot("guide.rst", root, param)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(view.HTML, `<div class="source-file">`))
+	assert.True(t, param.hasFileRenderer("guide.rst"))
+	assert.False(t, param.hasFileRenderer("guide.txt"))
+}
+
+func TestDirectoryListingGitStatus(t *testing.T) {
+	dir := newGitTestRepository(t, map[string]string{
+		"README.md":     "# README\n",
+		"same.md":       "same\n",
+		"docs/guide.md": "# Guide\n",
+	})
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "docs", "guide.md"), []byte("# Changed\n"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "new.md"), []byte("new\n"), 0o600))
+
+	root, err := os.OpenRoot(dir)
+	assert.Nil(t, err)
+
+	defer root.Close()
+
+	param := &Param{
+		DirectoryListingShowExtensions: ".md",
+		IsDirectoryMode:                true,
+		DirectoryPath:                  dir,
+		DirectoryRoot:                  root,
+	}
+
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		handler("", param, http.FileServer(http.Dir(dir)), watcher.NewDisabled()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, rec.Code, http.StatusOK)
+
+		return rec.Body.String()
+	}
+
+	body := get("/?view=index")
+	assert.True(t, strings.Contains(body, `<span class="file-name">docs</span>
+            <span class="git-status git-status-modified" title="modified">•</span>`))
+	assert.True(t, strings.Contains(body, `<span class="file-name">new.md</span>
+            <span class="git-status git-status-untracked" title="untracked">U</span>`))
+	// docs and new.md, both in the index and the file browser
+	assert.Equal(t, strings.Count(body, `<span class="git-status `), 4)
+	assert.True(t, strings.Contains(body, "Show changed files only"))
+
+	body = get("/?view=index&changed")
+	assert.True(t, strings.Contains(body, `<span class="file-name">docs/guide.md</span>`))
+	assert.True(t, strings.Contains(body, `<span class="file-name">new.md</span>`))
+	assert.False(t, strings.Contains(body, `<span class="file-name">same.md</span>`))
+	assert.True(t, strings.Contains(body, "Show all files"))
+
+	// Revisions have no working tree status
+	body = get("/?view=index&ref=v1")
+	assert.False(t, strings.Contains(body, "git-status"))
+}
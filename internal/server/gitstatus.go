@@ -0,0 +1,85 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/app"
+	"github.com/thiagokokada/gh-gfm-preview/internal/git"
+)
+
+// gitStatus returns the working tree status of the previewed directory, or
+// nil if it isn't in a git repository or a revision is being viewed. It is
+// read once per request.
+func (param *Param) gitStatus() *git.Status {
+	if !param.IsDirectoryMode || param.Ref != "" {
+		return nil
+	}
+
+	status, err := cached(param, statusKey{}, func() (*git.Status, error) {
+		status, err := git.ReadStatus(param.DirectoryPath)
+		if err != nil {
+			return nil, fmt.Errorf("git status error: %w", err)
+		}
+
+		return status, nil
+	})
+	if err != nil {
+		slog.Debug("No git status for directory", "dir", param.DirectoryPath, "error", err)
+
+		return nil
+	}
+
+	return status
+}
+
+// fileTree generates the file tree of currentPath, annotated with the git
+// status of its entries.
+func fileTree(status *git.Status, files, dirs []string, currentPath string) []FileTreeItem {
+	items := generateFileTree(files, dirs, currentPath)
+
+	for i, item := range items {
+		if item.Name != ".." {
+			items[i].GitStatus = status.Of(item.Path, item.IsDir)
+		}
+	}
+
+	return items
+}
+
+// changedFileTree lists the files changed below currentPath, with their path
+// relative to it as name.
+func changedFileTree(status *git.Status, currentPath string, extensions []string) []FileTreeItem {
+	var items []FileTreeItem
+
+	for _, file := range status.Changed(rootRelativePath(currentPath)) {
+		if !app.IsListedFile(file, extensions) {
+			continue
+		}
+
+		items = append(items, FileTreeItem{
+			Name:      strings.TrimPrefix(file, currentPath+"/"),
+			Path:      file,
+			GitStatus: status.Of(file, false),
+		})
+	}
+
+	return items
+}
+
+// GitStatusLabel returns the one letter badge of the git status of item.
+func (item FileTreeItem) GitStatusLabel() string {
+	switch item.GitStatus {
+	case "":
+		return ""
+	case git.StatusModified:
+		if item.IsDir {
+			return "•"
+		}
+
+		return "M"
+	default:
+		return strings.ToUpper(string(item.GitStatus[:1]))
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// anyHost disables the Host header check when given as an allowed host.
+const anyHost = "*"
+
+// allowedHosts returns the names accepted in the Host header: loopback
+// names, the host the server was started with and extra. When listening on
+// every interface, the addresses of the local interfaces and the hostname are
+// accepted too, since that is how other devices reach the preview.
+func allowedHosts(host string, addr net.Addr, extra []string) map[string]bool {
+	hosts := map[string]bool{
+		"localhost": true,
+		"127.0.0.1": true,
+		"::1":       true,
+	}
+
+	add := func(name string) {
+		if name != "" {
+			hosts[strings.ToLower(strings.Trim(name, "[]"))] = true
+		}
+	}
+
+	add(host)
+
+	for _, name := range extra {
+		add(name)
+	}
+
+	if _, ok := addr.(*net.UnixAddr); ok {
+		// only reachable through a proxy, which is the one receiving
+		// requests for arbitrary names. Origins are still checked against
+		// the Host header it passes on.
+		add(anyHost)
+
+		return hosts
+	}
+
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return hosts
+	}
+
+	add(tcpAddr.IP.String())
+
+	if !tcpAddr.IP.IsUnspecified() {
+		return hosts
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		add(hostname)
+	}
+
+	interfaceAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		slog.Warn("Unable to list interface addresses for the Host check", "error", err)
+
+		return hosts
+	}
+
+	for _, interfaceAddr := range interfaceAddrs {
+		if ipNet, ok := interfaceAddr.(*net.IPNet); ok {
+			add(ipNet.IP.String())
+		}
+	}
+
+	return hosts
+}
+
+// allowedOrigins returns the hosts of the pages allowed to use the internal
+// endpoints besides the preview itself: the names given with --allowed-host,
+// such as a reverse proxy that rewrites the Host header. The wildcard only
+// disables the Host check.
+func allowedOrigins(extra []string) map[string]bool {
+	origins := map[string]bool{}
+
+	for _, name := range extra {
+		if name != "" && name != anyHost {
+			origins[strings.ToLower(strings.Trim(name, "[]"))] = true
+		}
+	}
+
+	return origins
+}
+
+// hostName returns the lowercase host of a Host header value, without port.
+func hostName(hostport string) string {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+func isAllowedHost(hosts map[string]bool, hostport string) bool {
+	if hosts[anyHost] {
+		return true
+	}
+
+	name := hostName(hostport)
+
+	return hosts[name] || strings.HasSuffix(name, ".localhost")
+}
+
+// needsOriginCheck reports whether r may read data or change state, so it
+// must not come from another site.
+func needsOriginCheck(r *http.Request, basePath string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return true
+	}
+
+	urlPath := strings.TrimPrefix(r.URL.Path, basePath)
+
+	return urlPath == "/ws" || strings.HasPrefix(urlPath, "/__/")
+}
+
+// isSameOrigin reports whether the Origin header of r, if any, matches the
+// host it was sent to or is one of origins. Requests without Origin do not
+// come from a script running on another site.
+func isSameOrigin(origins map[string]bool, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host) || (u.Host != "" && origins[hostName(u.Host)])
+}
+
+// hostCheckHandler protects against DNS rebinding, where a malicious page
+// resolves its own name to 127.0.0.1 to read from the preview, and against
+// cross-site requests to the internal endpoints.
+func hostCheckHandler(hosts, origins map[string]bool, basePath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAllowedHost(hosts, r.Host) {
+			slog.Warn(
+				"Rejected request with unknown Host header, use --allowed-host to accept it",
+				"host", r.Host,
+				"remote_addr", r.RemoteAddr,
+				"url", r.URL.Path,
+			)
+			http.Error(w, "Forbidden: unknown host", http.StatusForbidden)
+
+			return
+		}
+
+		if needsOriginCheck(r, basePath) && !isSameOrigin(origins, r) {
+			slog.Warn(
+				"Rejected cross-origin request",
+				"origin", r.Header.Get("Origin"),
+				"host", r.Host,
+				"remote_addr", r.RemoteAddr,
+				"url", r.URL.Path,
+			)
+			http.Error(w, "Forbidden: cross-origin request", http.StatusForbidden)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestAllowedHosts(t *testing.T) {
+	hosts := allowedHosts("localhost", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 3333}, []string{"Preview.Example.com"})
+
+	assert.True(t, isAllowedHost(hosts, "localhost:3333"))
+	assert.True(t, isAllowedHost(hosts, "LOCALHOST:3333"))
+	assert.True(t, isAllowedHost(hosts, "127.0.0.1:3333"))
+	assert.True(t, isAllowedHost(hosts, "[::1]:3333"))
+	assert.True(t, isAllowedHost(hosts, "docs.localhost:3333"))
+	assert.True(t, isAllowedHost(hosts, "preview.example.com"))
+	assert.False(t, isAllowedHost(hosts, "attacker.example.com:3333"))
+	assert.False(t, isAllowedHost(hosts, "localhost.example.com:3333"))
+	assert.False(t, isAllowedHost(hosts, ""))
+
+	hosts = allowedHosts("0.0.0.0", &net.TCPAddr{IP: net.IPv4zero, Port: 3333}, nil)
+	assert.True(t, isAllowedHost(hosts, "0.0.0.0:3333"))
+	assert.False(t, isAllowedHost(hosts, "attacker.example.com:3333"))
+
+	hosts = allowedHosts("localhost", nil, []string{anyHost})
+	assert.True(t, isAllowedHost(hosts, "attacker.example.com:3333"))
+}
+
+func TestHostCheckHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	extra := []string{"preview.example.com"}
+	handler := hostCheckHandler(allowedHosts("localhost", nil, extra), allowedOrigins(extra), "", next)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		host       string
+		origin     string
+		wantStatus int
+	}{
+		{"Page", http.MethodGet, "/", "localhost:3333", "", http.StatusOK},
+		{"DNS rebinding", http.MethodGet, "/", "attacker.example.com:3333", "", http.StatusForbidden},
+		{"Same origin markdown", http.MethodGet, "/__/md", "localhost:3333", "http://localhost:3333", http.StatusOK},
+		{"Cross origin markdown", http.MethodGet, "/__/md", "localhost:3333", "http://attacker.example.com", http.StatusForbidden},
+		{"Cross origin WebSocket", http.MethodGet, "/ws", "localhost:3333", "http://attacker.example.com", http.StatusForbidden},
+		{"Other port WebSocket", http.MethodGet, "/ws", "localhost:3333", "http://localhost:8080", http.StatusForbidden},
+		{"Localhost subdomain WebSocket", http.MethodGet, "/ws", "localhost:3333", "http://app.localhost:3333", http.StatusForbidden},
+		{"Proxied Host", http.MethodPost, "/__/task", "127.0.0.1:3333", "https://preview.example.com", http.StatusOK},
+		{"Null origin task", http.MethodPost, "/__/task", "localhost:3333", "null", http.StatusForbidden},
+		{"Cross origin POST", http.MethodPost, "/README.md", "localhost:3333", "http://attacker.example.com", http.StatusForbidden},
+		{"Cross origin static", http.MethodGet, "/static/script.js", "localhost:3333", "http://attacker.example.com", http.StatusOK},
+		{"Without origin", http.MethodPost, "/__/task", "localhost:3333", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Host = tt.host
+
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, tt.wantStatus)
+		})
+	}
+}
+
+func TestHostCheckHandlerAnyHost(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	extra := []string{anyHost}
+	handler := hostCheckHandler(allowedHosts("localhost", nil, extra), allowedOrigins(extra), "", next)
+
+	serve := func(target, host, origin string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = host
+
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, serve("/", "preview.example.com", ""), http.StatusOK)
+	assert.Equal(t, serve("/ws", "preview.example.com", "https://preview.example.com"), http.StatusOK)
+	// the wildcard doesn't apply to origins
+	assert.Equal(t, serve("/ws", "preview.example.com", "https://attacker.example.com"), http.StatusForbidden)
+}
+
+func TestHostCheckHandlerUnixSocket(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := hostCheckHandler(allowedHosts("localhost", &net.UnixAddr{Name: "preview.sock", Net: "unix"}, nil), allowedOrigins(nil), "", next)
+
+	for _, tt := range []struct {
+		origin     string
+		wantStatus int
+	}{
+		{"https://preview.example.com", http.StatusOK},
+		{"https://attacker.example.com", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		req.Host = "preview.example.com"
+		req.Header.Set("Origin", tt.origin)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, rec.Code, tt.wantStatus)
+	}
+}
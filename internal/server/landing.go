@@ -0,0 +1,110 @@
+package server
+
+import (
+	"cmp"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/app"
+	"github.com/thiagokokada/gh-gfm-preview/internal/git"
+)
+
+// landingPage is the layout of the root directory, like a repository's front
+// page on GitHub: its files with their last commit, then the README or one
+// of the community health files, selected in tabs.
+type landingPage struct {
+	files  []LandingFileItem
+	commit LandingCommit
+	tabs   []LandingTab
+	// document is the file of the selected tab, if it isn't the README
+	document string
+}
+
+// showsLanding reports whether the README of currentPath is shown in the
+// landing layout, which is only done for the root of a repository.
+func (param *Param) showsLanding(currentPath string) bool {
+	return param.IsDirectoryMode && !param.Wiki && rootRelativePath(currentPath) == "."
+}
+
+// newLandingPage lays out tree, the entries of the root directory, with the
+// tab selected in the "tab" query parameter of r.
+func newLandingPage(r *http.Request, param *Param, tree []FileTreeItem) *landingPage {
+	landing := &landingPage{}
+	commits := lastCommits(param, tree)
+
+	var latest git.CommitInfo
+
+	for _, item := range tree {
+		if item.Name == ".." {
+			continue
+		}
+
+		commit, ok := commits[item.Path]
+		landing.files = append(landing.files, LandingFileItem{FileTreeItem: item, Commit: landingCommit(commit, ok)})
+
+		if ok && commit.Time.After(latest.Time) {
+			latest = commit
+		}
+	}
+
+	landing.commit = landingCommit(latest, latest.Hash != "")
+
+	selected := r.URL.Query().Get("tab")
+	landing.tabs = []LandingTab{{Name: "README"}}
+
+	fsys, err := param.directoryFS()
+	if err == nil {
+		for _, file := range app.FindCommunityFilesFS(fsys, ".") {
+			if file.Tab == selected {
+				landing.document = file.Path
+			}
+
+			landing.tabs = append(landing.tabs, LandingTab{Tab: file.Tab, Name: file.Name, Selected: file.Tab == selected})
+		}
+	}
+
+	landing.tabs[0].Selected = landing.document == ""
+
+	return landing
+}
+
+// uncommittedStatuses are the git statuses of files without a commit.
+var uncommittedStatuses = []git.FileStatus{git.StatusUntracked, git.StatusIgnored, git.StatusAdded}
+
+// lastCommits returns the last commit of the committed entries of tree, by
+// path. Entries whose history couldn't be read, e.g. in time, have none. The
+// history is only read again after new commits.
+func lastCommits(param *Param, tree []FileTreeItem) map[string]git.CommitInfo {
+	var names []string
+
+	for _, item := range tree {
+		// skipped since they have no commit
+		if item.Name == ".." || slices.Contains(uncommittedStatuses, item.GitStatus) {
+			continue
+		}
+
+		names = append(names, item.Path)
+	}
+
+	commits, err := param.commitCache.LastCommits(param.DirectoryPath, cmp.Or(param.Ref, "HEAD"), names)
+	if err != nil {
+		slog.Debug("Unable to read the git history of directory", "dir", param.DirectoryPath, "error", err)
+	}
+
+	return commits
+}
+
+func landingCommit(commit git.CommitInfo, ok bool) LandingCommit {
+	if !ok {
+		return LandingCommit{}
+	}
+
+	return LandingCommit{
+		Subject:  commit.Subject,
+		Hash:     commit.Hash,
+		Date:     commit.Time.Format("Jan 2, 2006"),
+		DateTime: commit.Time.Format(time.RFC3339),
+	}
+}
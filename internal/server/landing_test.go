@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
+)
+
+func TestRepositoryLanding(t *testing.T) {
+	dir := newGitTestRepository(t, map[string]string{
+		"README.md":                  "# Project\n",
+		"LICENSE":                    "MIT License\n",
+		".github/CODE_OF_CONDUCT.md": "# Be nice\n",
+		"docs/README.md":             "# Docs\n",
+	})
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "new.md"), []byte("new\n"), 0o600))
+
+	root, err := os.OpenRoot(dir)
+	assert.Nil(t, err)
+
+	defer root.Close()
+
+	param := &Param{
+		DirectoryListingShowExtensions: ".md",
+		IsDirectoryMode:                true,
+		DirectoryPath:                  dir,
+		DirectoryRoot:                  root,
+	}
+
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		handler("", param, http.FileServer(http.Dir(dir)), watcher.NewDisabled()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, rec.Code, http.StatusOK)
+
+		return rec.Body.String()
+	}
+
+	body := get("/")
+	assert.True(t, strings.Contains(body, `<div class="repository-landing">`))
+	assert.True(t, strings.Contains(body, `<span class="file-name">docs</span>`))
+	assert.True(t, strings.Contains(body, `<td class="landing-file-commit" title="Release">Release</td>`))
+	// Untracked files have no commit
+	assert.True(t, strings.Contains(body, `<span class="file-name">new.md</span>
+                  <span class="git-status git-status-untracked" title="untracked">U</span>
+                </a>
+              </td>
+              <td class="landing-file-commit" title=""></td>`))
+	assert.True(t, strings.Contains(body, `<a href="?" class="selected" aria-current="page">README</a>`))
+	assert.True(t, strings.Contains(body, `<a href="?tab=code-of-conduct">Code of conduct</a>`))
+	assert.True(t, strings.Contains(body, `<a href="?tab=license">License</a>`))
+	assert.False(t, strings.Contains(body, "tab=security"))
+	assert.True(t, strings.Contains(body, `id="project"`))
+
+	body = get("/?tab=code-of-conduct")
+	assert.True(t, strings.Contains(body, `<a href="?tab=code-of-conduct" class="selected" aria-current="page">Code of conduct</a>`))
+	assert.True(t, strings.Contains(body, `id="be-nice"`))
+	assert.True(t, strings.Contains(body, `documentPath: ".github\/CODE_OF_CONDUCT.md"`))
+
+	// Files without an extension are shown as text
+	body = get("/?tab=license")
+	assert.True(t, strings.Contains(body, `<a href="?tab=license" class="selected" aria-current="page">License</a>`))
+	assert.True(t, strings.Contains(body, "MIT License"))
+	assert.False(t, strings.Contains(body, "This file can't be previewed"))
+
+	// Only the root has the landing layout
+	body = get("/docs/")
+	assert.False(t, strings.Contains(body, `<div class="repository-landing">`))
+	assert.True(t, strings.Contains(body, `id="docs"`))
+}
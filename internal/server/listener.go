@@ -0,0 +1,113 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket
+// activation, see sd_listen_fds(3).
+const listenFDsStart = 3
+
+var errNotActivated = errors.New("not started by socket activation")
+
+// listen returns the listener the server accepts connections on: a socket
+// passed by systemd socket activation if there is one, otherwise the Unix
+// socket at server.Socket if set, otherwise a TCP socket.
+func (server *Server) listen() (net.Listener, error) {
+	listener, err := activatedListener()
+	if err == nil {
+		slog.Info("Using socket passed by systemd", "address", listener.Addr())
+
+		return listener, nil
+	}
+
+	if !errors.Is(err, errNotActivated) {
+		return nil, err
+	}
+
+	if server.Socket != "" {
+		return unixListener(server.Socket)
+	}
+
+	return getTCPListener(server.Host, server.resolvePort())
+}
+
+// activatedListener returns the first socket passed through LISTEN_FDS.
+func activatedListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errNotActivated
+	}
+
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, errNotActivated
+	}
+
+	if fds > 1 {
+		slog.Warn("Only the first socket passed by systemd is used", "count", fds)
+	}
+
+	// do not pass the sockets to child processes, e.g. code renderers
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(name)
+	}
+
+	file := os.NewFile(uintptr(listenFDsStart), "LISTEN_FD_3")
+	defer file.Close()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("socket activation listener error: %w", err)
+	}
+
+	return listener, nil
+}
+
+// unixListener listens on a Unix socket at path, only accessible by the
+// current user. A socket left behind by a previous run is replaced, but not
+// one that is still in use.
+func unixListener(path string) (net.Listener, error) {
+	listener, err := listenUnixSocket(path)
+
+	inUse := errors.Is(err, syscall.EADDRINUSE) || errors.Is(err, fs.ErrExist)
+	if inUse && isStaleSocket(path) {
+		slog.Debug("Removing stale socket", "path", path)
+
+		err = os.Remove(path)
+		if err != nil {
+			return nil, fmt.Errorf("stale socket remove error: %w", err)
+		}
+
+		listener, err = listenUnixSocket(path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+func isStaleSocket(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return false
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return true
+	}
+
+	conn.Close()
+
+	return false
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package server
+
+import (
+	"fmt"
+	"net"
+)
+
+// listenUnixSocket listens on a Unix socket at path, whose access is
+// controlled by the permissions of its directory on this platform.
+func listenUnixSocket(path string) (net.Listener, error) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("unix listener error: %w", err)
+	}
+
+	return listener, nil
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestUnixListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preview.sock")
+
+	listener, err := unixListener(path)
+	assert.Nil(t, err)
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+
+	// a socket in use is not replaced
+	_, err = unixListener(path)
+	assert.NotNil(t, err)
+
+	addr, ok := listener.Addr().(*net.UnixAddr)
+	assert.True(t, ok)
+	assert.Equal(t, addr.Name, path)
+
+	assert.Nil(t, listener.Close())
+
+	// neither the socket nor the private directory it was created in are
+	// left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 0)
+}
+
+func TestUnixListenerReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preview.sock")
+
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	assert.Nil(t, err)
+
+	// leave the socket file behind, as a killed process would
+	stale.SetUnlinkOnClose(false)
+	assert.Nil(t, stale.Close())
+
+	listener, err := unixListener(path)
+	assert.Nil(t, err)
+	assert.Nil(t, listener.Close())
+}
+
+func TestUnixListenerKeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preview.sock")
+	assert.Nil(t, os.WriteFile(path, []byte("not a socket"), 0o600))
+
+	_, err := unixListener(path)
+	assert.NotNil(t, err)
+
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "not a socket")
+}
+
+func TestListenWithoutSocketActivation(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	_, err := activatedListener()
+	assert.Equal(t, err, errNotActivated)
+
+	server := Server{Socket: filepath.Join(t.TempDir(), "preview.sock")}
+
+	listener, err := server.listen()
+	assert.Nil(t, err)
+
+	defer listener.Close()
+
+	_, ok := listener.Addr().(*net.UnixAddr)
+	assert.True(t, ok)
+	assert.True(t, isLoopback(listener.Addr()))
+	assert.True(t, isAllowedHost(allowedHosts("", listener.Addr(), nil), "preview.example.com"))
+}
@@ -0,0 +1,69 @@
+//go:build unix
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// listenUnixSocket listens on a Unix socket at path only accessible by the
+// current user. The socket is created in a private directory and linked at
+// path once its permissions are restricted, so other users can never connect
+// to it.
+func listenUnixSocket(path string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".gfm-preview-")
+	if err != nil {
+		return nil, fmt.Errorf("unix socket directory error: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	privatePath := filepath.Join(dir, "socket")
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: privatePath, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("unix listener error: %w", err)
+	}
+
+	// the name removed on close is path, not the private one
+	listener.SetUnlinkOnClose(false)
+
+	err = os.Chmod(privatePath, 0o600)
+	if err == nil {
+		err = os.Link(privatePath, path)
+	}
+
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("unix listener error: %w", err), listener.Close())
+	}
+
+	return &unixSocketListener{UnixListener: listener, path: path}, nil
+}
+
+// unixSocketListener is a listener on the Unix socket linked at path.
+type unixSocketListener struct {
+	*net.UnixListener
+
+	path string
+}
+
+func (l *unixSocketListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: "unix"}
+}
+
+func (l *unixSocketListener) Close() error {
+	err := l.UnixListener.Close()
+	if err != nil {
+		return fmt.Errorf("unix listener close error: %w", err)
+	}
+
+	err = os.Remove(l.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unix socket remove error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	_ "image/gif"  // register GIF for image.DecodeConfig
+	_ "image/jpeg" // register JPEG for image.DecodeConfig
+	_ "image/png"  // register PNG for image.DecodeConfig
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/app"
+	"github.com/thiagokokada/gh-gfm-preview/internal/git"
+)
+
+const (
+	mediaImage = "image"
+	mediaSVG   = "svg"
+	mediaAudio = "audio"
+	mediaVideo = "video"
+	mediaPDF   = "pdf"
+	mediaOther = "other"
+
+	// sniffLen is the number of bytes used to detect the MIME type of files
+	// with unknown extensions, see http.DetectContentType.
+	sniffLen = 512
+)
+
+var mediaKinds = map[string]string{
+	".apng": mediaImage,
+	".avif": mediaImage,
+	".bmp":  mediaImage,
+	".gif":  mediaImage,
+	".ico":  mediaImage,
+	".jpeg": mediaImage,
+	".jpg":  mediaImage,
+	".png":  mediaImage,
+	".webp": mediaImage,
+	".svg":  mediaSVG,
+	".aac":  mediaAudio,
+	".flac": mediaAudio,
+	".m4a":  mediaAudio,
+	".mp3":  mediaAudio,
+	".oga":  mediaAudio,
+	".opus": mediaAudio,
+	".wav":  mediaAudio,
+	".m4v":  mediaVideo,
+	".mov":  mediaVideo,
+	".mp4":  mediaVideo,
+	".ogv":  mediaVideo,
+	".webm": mediaVideo,
+	".pdf":  mediaPDF,
+}
+
+func mediaKind(filePath string) string {
+	if kind, ok := mediaKinds[strings.ToLower(path.Ext(filePath))]; ok {
+		return kind
+	}
+
+	return mediaOther
+}
+
+// isMediaFile reports whether file in fsys is shown in a viewer page in
+// directory mode, since it is neither a text file nor a file with a renderer.
+// Community health files, dotfiles like .gitignore and files without an
+// extension, like LICENSE, are text files unless their contents say
+// otherwise.
+func (param *Param) isMediaFile(fsys fs.FS, file string) bool {
+	textExtensions := app.ParseExtensions(param.DirectoryListingTextExtensions)
+
+	if app.IsTextFile(file, textExtensions) || app.IsMarkdownFile(file) || param.hasFileRenderer(file) {
+		return false
+	}
+
+	// the extension of a dotfile is usually its whole name or a suffix like
+	// .env.example
+	sniffed := app.IsCommunityFile(file) || path.Ext(file) == "" || strings.HasPrefix(path.Base(file), ".")
+	if !sniffed {
+		return true
+	}
+
+	return !strings.HasPrefix(mediaType(fsys, file), "text/")
+}
+
+// isDocumentRequest reports whether r is a browser navigation, as opposed to
+// e.g. an <img> loading the file.
+func isDocumentRequest(r *http.Request) bool {
+	if dest := r.Header.Get("Sec-Fetch-Dest"); dest != "" {
+		return dest == "document"
+	}
+
+	// Sec-Fetch-Dest is only sent in secure contexts
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// serveRawFile serves the contents of a file in the directory root, or of its
+// version at the git revision being previewed.
+func serveRawFile(w http.ResponseWriter, r *http.Request, param *Param, currentURLPath string, info fs.FileInfo) {
+	if param.Ref == "" {
+		serveRootFile(w, r, param, currentURLPath, info)
+
+		return
+	}
+
+	content, err := git.Show(filepath.Join(param.DirectoryPath, currentURLPath), param.Ref)
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, git.ErrInvalidRef) {
+			status = http.StatusBadRequest
+		}
+
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	http.ServeContent(w, r, path.Base(currentURLPath), info.ModTime(), bytes.NewReader(content))
+}
+
+// mediaURL returns the URL serving file as is. The modification time busts
+// the browser cache on live reload.
+func mediaURL(basePath, file string, modTime time.Time, ref string) string {
+	query := url.Values{"raw": {"1"}}
+	if ref != "" {
+		query.Set("ref", ref)
+	} else {
+		query.Set("v", strconv.FormatInt(modTime.UnixNano(), 36))
+	}
+
+	return basePath + (&url.URL{Path: "/" + file}).EscapedPath() + "?" + query.Encode()
+}
+
+// renderMediaView renders a viewer page for files that can't be shown as
+// text: images, audio and video players, PDFs and a download link for
+// everything else.
+func renderMediaView(fsys fs.FS, file string, param *Param) (markdownView, error) {
+	info, err := fs.Stat(fsys, file)
+	if err != nil {
+		return markdownView{}, fmt.Errorf("media stat error: %w", err)
+	}
+
+	name := html.EscapeString(path.Base(file))
+	rawURL := html.EscapeString(mediaURL(param.BasePath, file, info.ModTime(), param.Ref))
+	kind := mediaKind(file)
+
+	details := []string{formatSize(info.Size()), mediaType(fsys, file)}
+	if width, height, ok := imageDimensions(fsys, file); ok {
+		details = append(details, fmt.Sprintf("%d × %d pixels", width, height))
+	}
+
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, `<div class="media-file media-file-%s">`+"\n", kind)
+	fmt.Fprintf(&buf, `<div class="media-file-info">%s</div>`+"\n", html.EscapeString(strings.Join(details, " · ")))
+
+	switch kind {
+	case mediaImage, mediaSVG:
+		fmt.Fprintf(&buf, `<div class="media-file-image"><img class="media-file-zoomable" src="%s" alt="%s" title="Click to zoom"></div>`+"\n", rawURL, name)
+		buf.WriteString(imageCompareHTML(file, rawURL, param))
+
+		if kind == mediaSVG {
+			buf.WriteString(svgSourceHTML(fsys, file))
+		}
+	case mediaAudio:
+		fmt.Fprintf(&buf, `<audio controls preload="metadata" src="%s"></audio>`+"\n", rawURL)
+	case mediaVideo:
+		fmt.Fprintf(&buf, `<video controls preload="metadata" src="%s"></video>`+"\n", rawURL)
+	case mediaPDF:
+		fmt.Fprintf(&buf, `<iframe class="media-file-pdf" src="%s" title="%s"></iframe>`+"\n", rawURL, name)
+	default:
+		buf.WriteString(`<p class="media-file-notice">This file can't be previewed.</p>` + "\n")
+	}
+
+	fmt.Fprintf(&buf, `<p class="media-file-actions"><a href="%s" download="%s">Download</a> · <a href="%s">View raw</a></p>`+"\n", rawURL, name, rawURL)
+	buf.WriteString("</div>\n")
+
+	return markdownView{HTML: buf.String()}, nil
+}
+
+// imageCompareHTML shows the image side by side with its previous version in
+// git, if there is one. Only working tree files are compared.
+func imageCompareHTML(file, rawURL string, param *Param) string {
+	if param.Ref != "" {
+		return ""
+	}
+
+	ref, err := git.PreviousRevision(filepath.Join(param.DirectoryPath, file))
+	if err != nil {
+		slog.Debug("No previous version to compare", "file", file, "error", err)
+
+		return ""
+	}
+
+	label := ref
+	if len(label) > 7 {
+		label = label[:7]
+	}
+
+	oldURL := html.EscapeString(mediaURL(param.BasePath, file, time.Time{}, ref))
+
+	return fmt.Sprintf(`<details class="media-file-compare">
+<summary>Compare with previous version (%s)</summary>
+<div class="media-file-compare-images">
+<figure><img loading="lazy" src="%s" alt="Previous version"><figcaption>%s</figcaption></figure>
+<figure><img loading="lazy" src="%s" alt="Current version"><figcaption>Working tree</figcaption></figure>
+</div>
+</details>
+`, html.EscapeString(label), oldURL, html.EscapeString(label), rawURL)
+}
+
+func svgSourceHTML(fsys fs.FS, file string) string {
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return ""
+	}
+
+	source, err := app.SourceToHTML(file, string(content))
+	if err != nil {
+		return ""
+	}
+
+	return `<details class="media-file-source"><summary>Source</summary>` + "\n" + source + "</details>\n"
+}
+
+// mediaType returns the MIME type of file, detected from its contents when
+// the extension is unknown.
+func mediaType(fsys fs.FS, file string) string {
+	if mimeType := mime.TypeByExtension(path.Ext(file)); mimeType != "" {
+		return mimeType
+	}
+
+	f, err := fsys.Open(file)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+
+	b := make([]byte, sniffLen)
+	n, _ := io.ReadFull(f, b)
+
+	return http.DetectContentType(b[:n])
+}
+
+func imageDimensions(fsys fs.FS, file string) (int, int, bool) {
+	if mediaKind(file) != mediaImage {
+		return 0, 0, false
+	}
+
+	f, err := fsys.Open(file)
+	if err != nil {
+		return 0, 0, false
+	}
+	defer f.Close()
+
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return config.Width, config.Height, true
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d Bytes", size)
+	}
+
+	value := float64(size)
+	suffixes := []string{"KB", "MB", "GB", "TB"}
+
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+
+	return fmt.Sprintf("%.1f %s", value, suffixes[i])
+}
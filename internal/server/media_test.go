@@ -0,0 +1,192 @@
+package server
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/gittest"
+	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
+)
+
+func newMediaTestServer(t *testing.T, files map[string][]byte) (*httptest.Server, string) {
+	t.Helper()
+
+	testDir := t.TempDir()
+	for name, content := range files {
+		assert.Nil(t, os.WriteFile(filepath.Join(testDir, name), content, 0o600))
+	}
+
+	root, err := os.OpenRoot(testDir)
+	assert.Nil(t, err)
+
+	t.Cleanup(func() {
+		assert.Nil(t, root.Close())
+	})
+
+	param := &Param{
+		DirectoryListing:               true,
+		DirectoryListingShowExtensions: "*",
+		DirectoryListingTextExtensions: ".md",
+		IsDirectoryMode:                true,
+		DirectoryPath:                  testDir,
+		DirectoryRoot:                  root,
+	}
+
+	watcher, err := watcher.Init(testDir)
+	assert.Nil(t, err)
+
+	t.Cleanup(func() {
+		watcher.Close()
+	})
+
+	ts := httptest.NewServer(handler("", param, http.FileServer(http.Dir(testDir)), watcher))
+	t.Cleanup(ts.Close)
+
+	return ts, testDir
+}
+
+func getWithAccept(t *testing.T, url, accept string) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.Nil(t, err)
+	req.Header.Set("Accept", accept)
+
+	res, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+
+	return res, string(body)
+}
+
+func TestMediaViewer(t *testing.T) {
+	var pngData bytes.Buffer
+	assert.Nil(t, png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 3, 2))))
+
+	ts, _ := newMediaTestServer(t, map[string][]byte{
+		"image.png":    pngData.Bytes(),
+		"icon.svg":     []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`),
+		"song.mp3":     {0},
+		"data.bin":     {0, 1, 2},
+		"report.pdf":   []byte("%PDF-1.4"),
+		"Makefile":     []byte("all:\n"),
+		"blob":         {0, 1, 2},
+		".gitignore":   []byte("*.log\n"),
+		".env.example": []byte("PORT=3333\n"),
+	})
+
+	const navigation = "text/html,application/xhtml+xml"
+
+	res, body := getWithAccept(t, ts.URL+"/image.png", navigation)
+	assert.Equal(t, res.StatusCode, http.StatusOK)
+	assert.True(t, strings.Contains(body, `<div class="media-file media-file-image">`))
+	assert.True(t, strings.Contains(body, "3 × 2 pixels"))
+	assert.True(t, strings.Contains(body, "image/png"))
+	assert.True(t, strings.Contains(body, `src="/image.png?raw=1&amp;v=`))
+	assert.True(t, strings.Contains(body, `id="file-browser"`))
+
+	// Images embedded in pages are served as is
+	res, body = getWithAccept(t, ts.URL+"/image.png", "image/avif,image/webp,*/*")
+	assert.Equal(t, res.Header.Get("Content-Type"), "image/png")
+	assert.Equal(t, body, pngData.String())
+
+	res, _ = getWithAccept(t, ts.URL+"/image.png?raw=1", navigation)
+	assert.Equal(t, res.Header.Get("Content-Type"), "image/png")
+
+	_, body = getWithAccept(t, ts.URL+"/icon.svg", navigation)
+	assert.True(t, strings.Contains(body, `<details class="media-file-source">`))
+	assert.True(t, strings.Contains(body, `<div class="source-file">`))
+
+	_, body = getWithAccept(t, ts.URL+"/song.mp3", navigation)
+	assert.True(t, strings.Contains(body, `<audio controls preload="metadata" src="/song.mp3?raw=1`))
+
+	_, body = getWithAccept(t, ts.URL+"/report.pdf", navigation)
+	assert.True(t, strings.Contains(body, `<iframe class="media-file-pdf" src="/report.pdf?raw=1`))
+
+	_, body = getWithAccept(t, ts.URL+"/data.bin", navigation)
+	assert.True(t, strings.Contains(body, "This file can't be previewed."))
+	assert.True(t, strings.Contains(body, "3 Bytes · application/octet-stream"))
+	assert.True(t, strings.Contains(body, `download="data.bin"`))
+
+	// Files without an extension are shown by their contents
+	_, body = getWithAccept(t, ts.URL+"/Makefile", navigation)
+	assert.True(t, strings.Contains(body, `<div class="source-file">`))
+	assert.False(t, strings.Contains(body, `<div class="media-file`))
+
+	_, body = getWithAccept(t, ts.URL+"/blob", navigation)
+	assert.True(t, strings.Contains(body, "This file can't be previewed."))
+
+	// so are dotfiles, whatever their extension
+	for _, name := range []string{".gitignore", ".env.example"} {
+		_, body = getWithAccept(t, ts.URL+"/"+name, navigation)
+		assert.True(t, strings.Contains(body, `<div class="source-file">`))
+		assert.False(t, strings.Contains(body, `<div class="media-file`))
+	}
+}
+
+func TestMediaViewerComparesWithGit(t *testing.T) {
+	ts, dir := newMediaTestServer(t, map[string][]byte{"image.gif": []byte("GIF89a old")})
+
+	gittest.Init(t, dir)
+	gittest.Command(t, dir, "add", "image.gif")
+	gittest.Command(t, dir, "commit", "--quiet", "--message", "Add image")
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "image.gif"), []byte("GIF89a new"), 0o600))
+
+	_, body := getWithAccept(t, ts.URL+"/image.gif", "text/html")
+	assert.True(t, strings.Contains(body, "Compare with previous version (HEAD)"))
+	assert.True(t, strings.Contains(body, `src="/image.gif?raw=1&amp;ref=HEAD"`))
+
+	_, body = getWithAccept(t, ts.URL+"/image.gif?raw=1&ref=HEAD", "image/*")
+	assert.Equal(t, body, "GIF89a old")
+
+	res, _ := getWithAccept(t, ts.URL+"/image.gif?raw=1&ref=--output=x", "image/*")
+	assert.Equal(t, res.StatusCode, http.StatusBadRequest)
+}
+
+func TestIsDocumentRequest(t *testing.T) {
+	tests := []struct {
+		dest   string
+		accept string
+		want   bool
+	}{
+		{"document", "", true},
+		{"image", "text/html", false},
+		{"", "text/html,application/xhtml+xml", true},
+		{"", "image/webp,*/*", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/image.png", nil)
+		r.Header.Set("Sec-Fetch-Dest", tt.dest)
+		r.Header.Set("Accept", tt.accept)
+
+		assert.Equal(t, isDocumentRequest(r), tt.want)
+	}
+}
+
+func TestMediaURL(t *testing.T) {
+	assert.Equal(t, mediaURL("/preview", "dir/a b.png", time.Time{}, "HEAD~1"), "/preview/dir/a%20b.png?raw=1&ref=HEAD~1")
+	assert.True(t, strings.HasPrefix(mediaURL("", "a.png", time.Now(), ""), "/a.png?raw=1&v="))
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, formatSize(512), "512 Bytes")
+	assert.Equal(t, formatSize(1536), "1.5 KB")
+	assert.Equal(t, formatSize(5*1024*1024), "5.0 MB")
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+)
+
+// requestCache holds what is computed at most once while serving a request,
+// since several parts of a page need it.
+type requestCache struct {
+	values map[any]cachedValue
+}
+
+// revisionKey identifies the files of a directory as of a git revision.
+type revisionKey struct {
+	dir, ref string
+}
+
+// wikiKey identifies the wiki pages of a directory as of a git revision.
+type wikiKey revisionKey
+
+// statusKey identifies the working tree status of the previewed directory.
+type statusKey struct{}
+
+type cachedValue struct {
+	value any
+	err   error
+}
+
+// forRequest returns the param used to serve r, reading the revisions given
+// in its query, with an empty cache.
+func (param *Param) forRequest(r *http.Request) *Param {
+	requestParam := *param.withRevisions(r)
+	requestParam.cache = &requestCache{values: map[any]cachedValue{}}
+
+	return &requestParam
+}
+
+// cached returns the value of key, computing it the first time it's needed
+// while serving the request. Without a request cache, it's computed on every
+// call.
+func cached[T any](param *Param, key any, compute func() (T, error)) (T, error) {
+	if param.cache == nil {
+		return compute()
+	}
+
+	if c, ok := param.cache.values[key]; ok {
+		value, _ := c.value.(T)
+
+		return value, c.err
+	}
+
+	value, err := compute()
+	param.cache.values[key] = cachedValue{value: value, err: err}
+
+	return value, err
+}
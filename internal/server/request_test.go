@@ -0,0 +1,44 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestCached(t *testing.T) {
+	errCompute := errors.New("compute error")
+	calls := 0
+	compute := func() (int, error) {
+		calls++
+
+		return calls, errCompute
+	}
+
+	param := (&Param{}).forRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	value, err := cached(param, revisionKey{dir: "docs"}, compute)
+	assert.Equal(t, value, 1)
+	assert.True(t, errors.Is(err, errCompute))
+
+	value, err = cached(param, revisionKey{dir: "docs"}, compute)
+	assert.Equal(t, value, 1)
+	assert.True(t, errors.Is(err, errCompute))
+
+	value, _ = cached(param, revisionKey{dir: "wiki"}, compute)
+	assert.Equal(t, value, 2)
+
+	// a new request computes again
+	param = param.forRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+	value, _ = cached(param, revisionKey{dir: "docs"}, compute)
+	assert.Equal(t, value, 3)
+
+	// without a request cache, nothing is kept
+	value, _ = cached(&Param{}, revisionKey{dir: "docs"}, compute)
+	assert.Equal(t, value, 4)
+	value, _ = cached(&Param{}, revisionKey{dir: "docs"}, compute)
+	assert.Equal(t, value, 5)
+}
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"cmp"
+	"context"
+	"crypto/tls"
 	"embed"
 	"encoding/json"
 	"errors"
@@ -21,6 +24,7 @@ import (
 	"github.com/andybalholm/crlf"
 	"github.com/thiagokokada/gh-gfm-preview/internal/app"
 	"github.com/thiagokokada/gh-gfm-preview/internal/browser"
+	"github.com/thiagokokada/gh-gfm-preview/internal/git"
 	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
 	"golang.org/x/text/transform"
 )
@@ -48,11 +52,15 @@ var templateFuncs = template.FuncMap{
 
 var tmpl = template.Must(template.New("HTML Template").Funcs(templateFuncs).Parse(htmlTemplate))
 
-const defaultPort = 3333
+const (
+	defaultPort     = 3333
+	shutdownTimeout = 5 * time.Second
+)
 
 var (
 	rootNormalizer     = new(crlf.Normalize)
 	errNoDirectoryRoot = errors.New("directory root is not initialized")
+	errRefStdin        = errors.New("a git revision can't be previewed when reading from stdin")
 )
 
 func (server *Server) resolvePort() int {
@@ -80,7 +88,8 @@ func resolveFileMode(param *Param) (string, string, error) {
 	info, statErr := os.Stat(inputPath)
 	isDir := statErr == nil && info.IsDir()
 
-	if isDir && param.DirectoryListing {
+	// wikis are previewed as a whole
+	if isDir && (param.DirectoryListing || param.Wiki) {
 		return setupDirectoryMode(param, inputPath)
 	}
 
@@ -91,7 +100,13 @@ func setupDirectoryMode(param *Param, inputPath string) (string, string, error)
 	param.IsDirectoryMode = true
 	param.DirectoryPath = inputPath
 
-	readme, readmeErr := app.FindReadmeFS(os.DirFS(inputPath), ".")
+	if !param.Wiki && isWikiDirectory(inputPath) {
+		slog.Info("Previewing directory as a GitHub wiki", "dir", inputPath)
+
+		param.Wiki = true
+	}
+
+	readme, readmeErr := param.findReadmeFS(os.DirFS(inputPath), ".")
 	if readmeErr == nil {
 		param.ReadmeFile = filepath.Join(inputPath, readme)
 
@@ -117,15 +132,65 @@ func setupFileMode(param *Param, inputPath string) (string, string, error) {
 	return filename, filepath.Dir(filename), nil
 }
 
-func (server *Server) Serve(param *Param) error {
+// Serve starts the preview server and blocks until ctx is cancelled, at which
+// point clients are notified and the server is shut down gracefully.
+func (server *Server) Serve(ctx context.Context, param *Param) error {
 	host := server.Host
-	port := server.resolvePort()
+
+	basePath, err := normalizeBasePath(param.BasePath)
+	if err != nil {
+		return err
+	}
+
+	param.BasePath = basePath
 
 	filename, dir, err := resolveFileAndDir(param)
 	if err != nil {
 		return err
 	}
 
+	err = validateWiki(param)
+	if err != nil {
+		return err
+	}
+
+	if param.Ref != "" {
+		err = validateRef(dir, param)
+		if err != nil {
+			return err
+		}
+	}
+
+	if param.Autolink && param.AutolinkRepository == "" {
+		param.AutolinkRepository = detectRepository(dir)
+	}
+
+	if len(param.CodeRenderers) > 0 {
+		param.codeRenderers, err = app.NewCodeRenderers(param.CodeRenderers, param.CodeRendererTimeout)
+		if err != nil {
+			return fmt.Errorf("code renderers error: %w", err)
+		}
+	}
+
+	param.markupRenderers, err = app.NewMarkupRenderers(param.MarkupRenderers, param.CodeRendererTimeout)
+	if err != nil {
+		return fmt.Errorf("markup renderers error: %w", err)
+	}
+
+	param.commitCache = &git.CommitCache{}
+
+	err = param.validateCodeStyles()
+	if err != nil {
+		return err
+	}
+
+	if param.CustomTemplate != "" {
+		param.template, err = loadCustomTemplate(param.CustomTemplate)
+		if err != nil {
+			return err
+		}
+	}
+
 	if param.IsDirectoryMode {
 		root, rootErr := os.OpenRoot(param.DirectoryPath)
 		if rootErr != nil {
@@ -147,24 +212,80 @@ func (server *Server) Serve(param *Param) error {
 	watcher := initWatcher(watchTarget, param)
 	defer watcher.Close()
 
+	if param.CustomCSS != "" {
+		err = watcher.AddDirectory(filepath.Dir(param.CustomCSS))
+		if err != nil {
+			slog.Warn("Custom CSS will not be reloaded on changes", "error", err)
+		}
+	}
+
 	serveMux := http.NewServeMux()
 	serveMux.Handle("/", wrapHandler(handler(filename, param, http.FileServer(http.Dir(dir)), watcher)))
 	serveMux.Handle("/static/", wrapHandler(http.StripPrefix("/static/", http.FileServer(http.FS(staticFS)))))
 	serveMux.Handle("/__/md", wrapHandler(mdHandler(filename, param)))
 
-	serveMux.Handle("/ws", wsHandler(watcher))
+	serveMux.Handle(apiPrefix, wrapHandler(apiHandler(filename, param)))
+	serveMux.Handle("/__/chroma/", wrapHandler(chromaCSSHandler()))
 
-	listener, err := getTCPListener(host, port)
+	if param.CustomCSS != "" {
+		serveMux.Handle("/__/custom.css", wrapHandler(customCSSHandler(param.CustomCSS)))
+	}
+
+	if param.InteractiveTasks {
+		serveMux.Handle("/__/task", wrapHandler(taskHandler(filename, param)))
+	}
+
+	broker := startBroker(watcher)
+	serveMux.Handle("/ws", wsHandler(broker))
+	serveMux.Handle("/__/events", sseHandler(broker))
+
+	listener, err := server.listen()
 	if err != nil {
 		return err
 	}
 
 	address := listener.Addr()
-	url := fmt.Sprintf("http://%s/", address)
+	hosts := allowedHosts(host, address, param.AllowedHosts)
+	scheme := "http"
+
+	if param.TLS {
+		tlsConfig, tlsErr := param.tlsConfig(hosts)
+		if tlsErr != nil {
+			return errors.Join(tlsErr, listener.Close())
+		}
 
-	slog.Info("Accepting connections", "url", url)
+		listener = tls.NewListener(listener, tlsConfig)
+		scheme = "https"
+	}
+
+	url := fmt.Sprintf("%s://%s%s/", scheme, address, param.BasePath)
+
+	var serverHandler http.Handler = serveMux
+
+	if param.BasePath != "" {
+		serverHandler = basePathHandler(param.BasePath, serverHandler)
+	}
+
+	token := param.resolveToken(address)
+	if token != "" {
+		serverHandler = authHandler(token, tokenCookieName(address), serverHandler)
+		url += "?" + tokenParam + "=" + token
+	}
 
-	if param.AutoOpen {
+	serverHandler = hostCheckHandler(hosts, allowedOrigins(param.AllowedHosts), param.BasePath, serverHandler)
+
+	autoOpen := param.AutoOpen
+
+	if _, ok := address.(*net.UnixAddr); ok {
+		// browsers cannot connect to a Unix socket themselves
+		slog.Info("Accepting connections", "socket", address.String())
+
+		autoOpen = false
+	} else {
+		slog.Info("Accepting connections", "url", url)
+	}
+
+	if autoOpen {
 		slog.Info("Opening URL in your browser", "url", url)
 
 		go func() {
@@ -176,19 +297,95 @@ func (server *Server) Serve(param *Param) error {
 	}
 
 	hs := &http.Server{
-		Handler:      serveMux,
+		Handler:      serverHandler,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
 
-	err = hs.Serve(listener)
-	if err != nil {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	if param.ExitAfterDisconnect > 0 || param.ExitIdleTimeout > 0 {
+		if param.Reload {
+			go exitWhenUnused(ctx, broker.counts, param.ExitAfterDisconnect, param.ExitIdleTimeout, stop)
+		} else {
+			slog.Warn("Exiting when unused requires live reload, ignoring")
+		}
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- hs.Serve(listener)
+	}()
+
+	select {
+	case err = <-errCh:
 		return fmt.Errorf("http server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down server")
+
+	return shutdown(hs, broker, watcher)
+}
+
+// validateRef fails fast if the revision given with --ref can't be read.
+func validateRef(dir string, param *Param) error {
+	if param.UseStdin {
+		return errRefStdin
+	}
+
+	_, err := git.NewFS(dir, param.Ref)
+	if err != nil {
+		return fmt.Errorf("git revision error: %w", err)
+	}
+
+	return nil
+}
+
+// shutdown stops accepting connections, tells the clients that the preview
+// stopped and stops the watcher, giving up after shutdownTimeout.
+func shutdown(hs *http.Server, broker *wsBroker, watcher *watcher.Watcher) error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	watcher.Stop()
+
+	// Server-Sent Events streams are only closed once the broker tells them
+	// to, so the broker needs to shut down while the server waits for them.
+	brokerErrCh := make(chan error, 1)
+
+	go func() {
+		brokerErrCh <- broker.shutdown(ctx)
+	}()
+
+	err := hs.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("http server shutdown error: %w", err)
+	}
+
+	err = <-brokerErrCh
+	if err != nil {
+		return fmt.Errorf("live reload shutdown error: %w", err)
 	}
 
 	return nil
 }
 
+func detectRepository(dir string) string {
+	repository, err := git.RemoteRepository(dir)
+	if err != nil {
+		slog.Warn("Repository not detected, only mentions and qualified references will be autolinked", "error", err)
+
+		return ""
+	}
+
+	slog.Info("Autolinking references", "repository", repository)
+
+	return repository
+}
+
 func watcherTarget(dir string) string {
 	return dir
 }
@@ -208,6 +405,16 @@ func initWatcher(watchTarget string, param *Param) *watcher.Watcher {
 
 func handler(filename string, param *Param, handler http.Handler, watcher *watcher.Watcher) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, key := range []string{"ref", "diff"} {
+			if ref := r.URL.Query().Get(key); ref != "" && !git.ValidRef(ref) {
+				http.Error(w, fmt.Sprintf("%s: %q", git.ErrInvalidRef, ref), http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		param := param.forRequest(r)
+
 		if !param.IsDirectoryMode {
 			// Original single-file mode
 			if !strings.HasSuffix(r.URL.Path, ".md") && r.URL.Path != "/" {
@@ -219,16 +426,17 @@ func handler(filename string, param *Param, handler http.Handler, watcher *watch
 			markdownView := mdResponse(w, filename, param)
 
 			templateParam := TemplateParam{
-				Title:        getTitle(filename),
-				Body:         template.HTML(markdownView.HTML),         //nolint:gosec // G203: rendered Markdown is intentionally raw HTML
-				HeadingsHTML: template.HTML(markdownView.HeadingsHTML), //nolint:gosec // G203: rendered Markdown is intentionally raw HTML
-				HasHeadings:  markdownView.HasHeadings,
-				Host:         r.Host,
-				Reload:       param.Reload,
-				Mode:         param.getMode().String(),
+				Title:            getTitle(filename),
+				Body:             template.HTML(markdownView.HTML),         //nolint:gosec // G203: rendered Markdown is intentionally raw HTML
+				HeadingsHTML:     template.HTML(markdownView.HeadingsHTML), //nolint:gosec // G203: rendered Markdown is intentionally raw HTML
+				HasHeadings:      markdownView.HasHeadings,
+				InteractiveTasks: param.interactiveTasks(),
+				Host:             r.Host,
+				Reload:           param.Reload,
+				Mode:             param.getMode().String(),
 			}
 
-			renderTemplate(w, templateParam)
+			renderTemplate(w, param, templateParam)
 
 			return
 		}
@@ -238,8 +446,20 @@ func handler(filename string, param *Param, handler http.Handler, watcher *watch
 	})
 }
 
-func renderTemplate(w http.ResponseWriter, templateParam TemplateParam) {
-	err := tmpl.Execute(w, templateParam)
+func renderTemplate(w http.ResponseWriter, param *Param, templateParam TemplateParam) {
+	templateParam.BasePath = param.BasePath
+	templateParam.ProtocolVersion = protocolVersion
+	templateParam.CustomCSS = param.CustomCSS != ""
+	templateParam.CodeLightStyleURL = param.BasePath + param.lightCodeStyleURL()
+	templateParam.CodeDarkStyleURL = param.BasePath + param.darkCodeStyleURL()
+	templateParam.Ref = param.Ref
+
+	t := tmpl
+	if param.template != nil {
+		t = param.template
+	}
+
+	err := t.Execute(w, templateParam)
 	if err != nil {
 		slog.Error("Template execute error", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -251,6 +471,15 @@ func getMarkdown(filename string, param *Param) (string, error) {
 		return param.StdinContent, nil
 	}
 
+	if param.Ref != "" {
+		fsys, err := git.NewFS(filepath.Dir(filename), param.Ref)
+		if err != nil {
+			return "", fmt.Errorf("git revision error: %w", err)
+		}
+
+		return readRootMarkdown(fsys, filepath.Base(filename))
+	}
+
 	markdown, err := app.Slurp(filename)
 	if err != nil {
 		return "", fmt.Errorf("get markdown error: %w", err)
@@ -265,13 +494,13 @@ func mdResponse(w http.ResponseWriter, filename string, param *Param) markdownVi
 		return writeMarkdownReadError(w, err)
 	}
 
-	return writeMarkdownViewResponse(w, markdown, param)
+	return writeMarkdownViewResponse(w, filename, markdown, param)
 }
 
-func writeMarkdownViewResponse(w http.ResponseWriter, markdown string, param *Param) markdownView {
+func writeMarkdownViewResponse(w http.ResponseWriter, filename, markdown string, param *Param) markdownView {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	markdownView, err := renderMarkdownView(markdown, param)
+	markdownView, err := renderFileOrDiffView(filename, markdown, previousFile(filename), param)
 	if err != nil {
 		return writeMarkdownRenderError(w, err, param)
 	}
@@ -279,8 +508,143 @@ func writeMarkdownViewResponse(w http.ResponseWriter, markdown string, param *Pa
 	return markdownView
 }
 
+// renderFileView renders content according to the type of filename: CSV and
+// TSV files become tables, Jupyter notebooks are rendered cell by cell and
+// other text files in directory mode are shown as source code. Everything
+// else is rendered as Markdown.
+func renderFileView(filename, content string, param *Param) (markdownView, error) {
+	if delimiter, ok := app.TableDelimiter(filename); ok {
+		return markdownView{HTML: app.TableToHTML(content, delimiter)}, nil
+	}
+
+	if app.IsNotebookFile(filename) {
+		return renderNotebookView(content, param)
+	}
+
+	if renderer, ok := param.markupRenderers.Get(filename); ok {
+		view, err := renderMarkupView(renderer, content)
+		if !errors.Is(err, app.ErrNoMarkupRenderer) {
+			return view, err
+		}
+
+		slog.Warn("Showing markup file as source", "file", filename, "error", err)
+
+		return renderSourceView(filename, content)
+	}
+
+	if param.IsDirectoryMode && !app.IsMarkdownFile(filename) {
+		return renderSourceView(filename, content)
+	}
+
+	return renderMarkdownView(content, param)
+}
+
+// renderFileOrDiffView renders content with renderFileView or, when comparing
+// revisions, its rendered changes since the version read by readPrevious.
+func renderFileOrDiffView(filename, content string, readPrevious func(*Param) (string, error), param *Param) (markdownView, error) {
+	if param.diffRef == "" {
+		return renderFileView(filename, content, param)
+	}
+
+	previousParam := *param
+	previousParam.Ref = param.diffRef
+	previousParam.diffRef = ""
+
+	var previousView markdownView
+
+	// files added since the revision are compared with an empty document
+	previous, err := readPrevious(&previousParam)
+	switch {
+	case err == nil:
+		previousView, err = renderFileView(filename, previous, &previousParam)
+		if err != nil {
+			return markdownView{}, err
+		}
+	case !errors.Is(err, app.ErrFileNotFound):
+		return markdownView{}, err
+	}
+
+	view, err := renderFileView(filename, content, param)
+	if err != nil {
+		return markdownView{}, err
+	}
+
+	diff, err := app.RichDiff(previousView.HTML, view.HTML)
+	if err != nil {
+		return markdownView{}, fmt.Errorf("rich diff error: %w", err)
+	}
+
+	summary := "Showing changes since"
+	if previousView.HTML == view.HTML {
+		summary = "No changes since"
+	}
+
+	view.HTML = fmt.Sprintf(`<p class="rich-diff-info">%s <code>%s</code>. <a href="?%s">View file</a></p>`+"\n",
+		summary, html.EscapeString(param.diffRef), html.EscapeString(param.fileQuery)) + diff
+
+	return view, nil
+}
+
+// previousFile reads filename as of another revision in single file mode.
+func previousFile(filename string) func(*Param) (string, error) {
+	return func(previousParam *Param) (string, error) {
+		return getMarkdown(filename, previousParam)
+	}
+}
+
+// hasFileRenderer reports whether filename is rendered by renderFileView even
+// if it isn't one of the configured text files.
+func (param *Param) hasFileRenderer(filename string) bool {
+	_, isMarkup := param.markupRenderers.Get(filename)
+
+	return isMarkup || app.IsTableFile(filename) || app.IsNotebookFile(filename)
+}
+
+func renderMarkupView(renderer app.Renderer, markup string) (markdownView, error) {
+	html, err := renderer.Render(markup)
+	if err != nil {
+		return markdownView{}, fmt.Errorf("markup convert error: %w", err)
+	}
+
+	headingsHTML, hasHeadings := renderHeadingsHTML(html)
+
+	return markdownView{
+		HTML:         html,
+		HeadingsHTML: headingsHTML,
+		HasHeadings:  hasHeadings,
+	}, nil
+}
+
+func renderSourceView(filename, source string) (markdownView, error) {
+	html, err := app.SourceToHTML(filename, source)
+	if err != nil {
+		return markdownView{}, fmt.Errorf("source convert error: %w", err)
+	}
+
+	return markdownView{HTML: html}, nil
+}
+
+func renderNotebookView(notebook string, param *Param) (markdownView, error) {
+	// Task lines refer to a cell, not to the notebook file
+	renderParam := *param
+	renderParam.InteractiveTasks = false
+
+	html, err := app.NotebookToHTML(notebook, param.MarkdownMode, renderParam.renderOptions()...)
+	if err != nil {
+		return markdownView{}, fmt.Errorf("notebook convert error: %w", err)
+	}
+
+	headingsHTML, hasHeadings := renderHeadingsHTML(html)
+
+	return markdownView{
+		HTML:         html,
+		HeadingsHTML: headingsHTML,
+		HasHeadings:  hasHeadings,
+	}, nil
+}
+
 func renderMarkdownView(markdown string, param *Param) (markdownView, error) {
-	html, err := app.ToHTML(markdown, param.MarkdownMode)
+	html, err := app.ToHTML(markdown, param.MarkdownMode, param.renderOptions()...)
 	if err != nil {
 		return markdownView{}, fmt.Errorf("markdown convert error: %w", err)
 	}
@@ -294,6 +658,92 @@ func renderMarkdownView(markdown string, param *Param) (markdownView, error) {
 	}, nil
 }
 
+func (param *Param) renderOptions() []app.Option {
+	var opts []app.Option
+
+	if param.Autolink {
+		opts = append(opts, app.WithReferenceLinks(param.AutolinkRepository))
+	}
+
+	if param.interactiveTasks() {
+		opts = append(opts, app.WithInteractiveTasks())
+	}
+
+	if param.codeRenderers != nil {
+		opts = append(opts, app.WithCodeRenderers(param.codeRenderers))
+	}
+
+	if param.CodeLineNumbers {
+		opts = append(opts, app.WithLineNumbers())
+	}
+
+	if param.wikiPages != nil {
+		opts = append(opts, app.WithWikiLinks(param.wikiPages, param.BasePath))
+	}
+
+	return opts
+}
+
+// interactiveTasks reports whether task list checkboxes can be toggled, which
+// requires a source file to write back to.
+func (param *Param) interactiveTasks() bool {
+	return param.InteractiveTasks && !param.UseStdin && param.Ref == "" && param.diffRef == ""
+}
+
+// withRevisions returns param reading files at the git revision in the "ref"
+// query parameter of r, and comparing them with the one in "diff" (HEAD if
+// empty), if these are given.
+func (param *Param) withRevisions(r *http.Request) *Param {
+	query := r.URL.Query()
+	ref := query.Get("ref")
+
+	if (ref == "" || ref == param.Ref) && !query.Has("diff") {
+		return param
+	}
+
+	revisionParam := *param
+	if ref != "" {
+		revisionParam.Ref = ref
+	}
+
+	if query.Has("diff") {
+		revisionParam.diffRef = cmp.Or(query.Get("diff"), "HEAD")
+
+		// the page without the diff, "path" only selects the file in the API
+		query.Del("diff")
+		query.Del("path")
+		revisionParam.fileQuery = query.Encode()
+	}
+
+	return &revisionParam
+}
+
+// contentFS returns the files of root, as of the git revision being
+// previewed if there is one. Revisions are read once per request.
+func (param *Param) contentFS(root *os.Root) (fs.FS, error) {
+	if param.Ref == "" {
+		return root.FS(), nil
+	}
+
+	return cached(param, revisionKey{dir: root.Name(), ref: param.Ref}, func() (fs.FS, error) {
+		fsys, err := git.NewFS(root.Name(), param.Ref)
+		if err != nil {
+			return nil, fmt.Errorf("git revision error: %w", err)
+		}
+
+		return fsys, nil
+	})
+}
+
+// directoryFS returns the files served in directory mode.
+func (param *Param) directoryFS() (fs.FS, error) {
+	if param.DirectoryRoot == nil {
+		return nil, errNoDirectoryRoot
+	}
+
+	return param.contentFS(param.DirectoryRoot)
+}
+
 func writeMarkdownReadError(w http.ResponseWriter, err error) markdownView {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
@@ -318,6 +768,7 @@ func writeMarkdownRenderError(w http.ResponseWriter, err error, param *Param) ma
 
 func mdHandler(filename string, param *Param) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		param := param.forRequest(r)
 		pathParam := r.URL.Query().Get("path")
 
 		if pathParam != "" && param.IsDirectoryMode {
@@ -374,7 +825,7 @@ func handleSingleFileMarkdownRequest(w http.ResponseWriter, filename string, par
 		return
 	}
 
-	markdownView, err := renderMarkdownView(markdown, param)
+	markdownView, err := renderFileOrDiffView(filename, markdown, previousFile(filename), param)
 	if err != nil {
 		writeMarkdownJSONErrorResponse(w, err, title)
 
@@ -415,17 +866,44 @@ func renderMarkdownFromOpenedRoot(pathParam string, root *os.Root, param *Param)
 		return markdownView{}, "", err
 	}
 
-	file, title, err := resolveRootMarkdownTarget(root, normalizedPath)
+	fsys, err := param.contentFS(root)
 	if err != nil {
 		return markdownView{}, "", err
 	}
 
-	markdown, err := readRootMarkdown(root, file)
+	file, title, err := resolveRootMarkdownTarget(fsys, normalizedPath, param)
 	if err != nil {
 		return markdownView{}, "", err
 	}
 
-	view, err := renderMarkdownView(markdown, param)
+	if param.IsDirectoryMode && param.isMediaFile(fsys, file) {
+		view, err := renderMediaView(fsys, file, param)
+
+		return view, title, err
+	}
+
+	markdown, err := readRootMarkdown(fsys, file)
+	if err != nil {
+		return markdownView{}, "", err
+	}
+
+	readPrevious := func(previousParam *Param) (string, error) {
+		fsys, err := previousParam.contentFS(root)
+		if err != nil {
+			return "", err
+		}
+
+		return readRootMarkdown(fsys, file)
+	}
+
+	render := renderFileOrDiffView
+	if param.Wiki {
+		render = func(file, markdown string, readPrevious func(*Param) (string, error), param *Param) (markdownView, error) {
+			return renderWikiView(fsys, file, markdown, readPrevious, param)
+		}
+	}
+
+	view, err := render(file, markdown, readPrevious, param)
 	if err != nil {
 		return markdownView{}, "", err
 	}
@@ -462,10 +940,10 @@ func writeMarkdownJSONErrorResponse(w http.ResponseWriter, err error, title stri
 	}, title)
 }
 
-func resolveRootMarkdownTarget(root *os.Root, pathParam string) (string, string, error) {
-	info, err := root.Stat(pathParam)
+func resolveRootMarkdownTarget(fsys fs.FS, pathParam string, param *Param) (string, string, error) {
+	info, err := fs.Stat(fsys, pathParam)
 	if err == nil && info.IsDir() {
-		readme, readmeErr := app.FindReadmeFS(root.FS(), rootRelativePath(pathParam))
+		readme, readmeErr := param.findReadmeFS(fsys, rootRelativePath(pathParam))
 		if readmeErr == nil {
 			return readme, path.Base(readme), nil
 		}
@@ -499,8 +977,8 @@ func normalizeRootPath(pathParam string) (string, bool) {
 	return cleaned, true
 }
 
-func readRootMarkdown(root *os.Root, path string) (string, error) {
-	b, err := root.ReadFile(path)
+func readRootMarkdown(fsys fs.FS, path string) (string, error) {
+	b, err := fs.ReadFile(fsys, path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return "", fmt.Errorf("%w: %s", app.ErrFileNotFound, path)
@@ -509,6 +987,10 @@ func readRootMarkdown(root *os.Root, path string) (string, error) {
 		return "", fmt.Errorf("root read error: %w", err)
 	}
 
+	return normalizeNewlines(b)
+}
+
+func normalizeNewlines(b []byte) (string, error) {
 	t, _, err := transform.Bytes(rootNormalizer, b)
 	if err != nil {
 		return "", fmt.Errorf("CRLF normalization error: %w", err)
@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/gittest"
 	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
 )
 
@@ -354,6 +355,161 @@ func TestMdHandlerRendersMermaid(t *testing.T) {
 	assert.True(t, strings.Contains(payload.HTML, `class="language-mermaid"`))
 }
 
+func TestMdHandlerRendersReferenceLinks(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	err := os.WriteFile(filename, []byte("Fixed in #42 by @octocat"), 0o600)
+	assert.Nil(t, err)
+
+	param := &Param{Autolink: true, AutolinkRepository: "owner/repo"}
+
+	req := httptest.NewRequest(http.MethodGet, "/__/md", nil)
+	rec := httptest.NewRecorder()
+
+	mdHandler(filename, param).ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	var payload mdResponseJSON
+
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	assert.Nil(t, err)
+
+	assert.True(t, strings.Contains(payload.HTML, `href="https://github.com/owner/repo/issues/42"`))
+	assert.True(t, strings.Contains(payload.HTML, `href="https://github.com/octocat"`))
+}
+
+// newGitTestRepository returns a repository with files committed and tagged
+// as v1.
+func newGitTestRepository(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o700))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	gittest.Init(t, dir)
+	gittest.Command(t, dir, "add", ".")
+	gittest.Command(t, dir, "commit", "--quiet", "--message", "Release")
+	gittest.Command(t, dir, "tag", "v1")
+
+	return dir
+}
+
+func TestGitRevisionPreview(t *testing.T) {
+	dir := newGitTestRepository(t, map[string]string{
+		"README.md":     "# Old README\r\n",
+		"docs/guide.md": "# Guide v1\n",
+	})
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# New README\n"), 0o600))
+	assert.Nil(t, os.RemoveAll(filepath.Join(dir, "docs")))
+
+	root, err := os.OpenRoot(dir)
+	assert.Nil(t, err)
+
+	defer root.Close()
+
+	param := &Param{
+		DirectoryListing:               true,
+		DirectoryListingShowExtensions: ".md",
+		DirectoryListingTextExtensions: ".md",
+		InteractiveTasks:               true,
+		IsDirectoryMode:                true,
+		DirectoryPath:                  dir,
+		DirectoryRoot:                  root,
+	}
+
+	getMd := func(query string) (int, mdResponseJSON) {
+		rec := httptest.NewRecorder()
+		mdHandler("", param).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/__/md?"+query, nil))
+
+		var payload mdResponseJSON
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+
+		return rec.Code, payload
+	}
+
+	status, payload := getMd("path=README.md&ref=v1")
+	assert.Equal(t, status, http.StatusOK)
+	assert.True(t, strings.Contains(payload.HTML, "Old README"))
+	assert.False(t, strings.Contains(payload.HTML, "\r"))
+
+	_, payload = getMd("path=docs/guide.md&ref=v1")
+	assert.True(t, strings.Contains(payload.HTML, "Guide v1"))
+
+	_, payload = getMd("path=README.md")
+	assert.True(t, strings.Contains(payload.HTML, "New README"))
+
+	status, _ = getMd("path=docs/guide.md")
+	assert.Equal(t, status, http.StatusNotFound)
+
+	status, _ = getMd("path=README.md&ref=missing")
+	assert.Equal(t, status, http.StatusNotFound)
+
+	watcher, err := watcher.Init(dir)
+	assert.Nil(t, err)
+
+	defer watcher.Close()
+
+	rec := httptest.NewRecorder()
+	handler("", param, http.FileServer(http.Dir(dir)), watcher).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/?ref=v1", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "Viewing files as of revision <code>v1</code>"))
+	assert.False(t, param.withRevisions(httptest.NewRequest(http.MethodGet, "/?ref=v1", nil)).interactiveTasks())
+
+	rec = httptest.NewRecorder()
+	handler("", param, http.FileServer(http.Dir(dir)), watcher).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/", nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	assert.Nil(t, validateRef(dir, &Param{Ref: "v1"}))
+	assert.NotNil(t, validateRef(dir, &Param{Ref: "missing"}))
+	assert.NotNil(t, validateRef(dir, &Param{Ref: "v1", UseStdin: true}))
+}
+
+func TestMdHandlerRichDiff(t *testing.T) {
+	dir := newGitTestRepository(t, map[string]string{"README.md": "# Title\n\nThe quick fox.\n\nRemoved.\n"})
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Title\n\nThe slow fox.\n"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "NEW.md"), []byte("Added.\n"), 0o600))
+
+	filename := filepath.Join(dir, "README.md")
+	param := &Param{InteractiveTasks: true}
+
+	getMd := func(query string) (int, mdResponseJSON) {
+		rec := httptest.NewRecorder()
+		mdHandler(filename, param).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/__/md?"+query, nil))
+
+		var payload mdResponseJSON
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+
+		return rec.Code, payload
+	}
+
+	_, payload := getMd("diff=")
+	assert.True(t, strings.Contains(payload.HTML, "Showing changes since <code>HEAD</code>."))
+	assert.True(t, strings.Contains(payload.HTML, `<a href="?">View file</a>`))
+	assert.True(t, strings.Contains(payload.HTML, "<p>The <del>quick</del><ins>slow</ins> fox.</p>"))
+	assert.True(t, strings.Contains(payload.HTML, `<div class="rich-diff-removed">`+"\n<p>Removed.</p>"))
+	assert.True(t, payload.HasHeadings)
+
+	_, payload = getMd("diff=v1&path=NEW.md")
+	assert.True(t, strings.Contains(payload.HTML, `<div class="rich-diff-added">`+"\n<p>Added.</p>"))
+
+	// the link back to the file keeps the rest of the page query
+	_, payload = getMd("diff=v1&ref=v1&theme=dark")
+	assert.True(t, strings.Contains(payload.HTML, "No changes since <code>v1</code>."))
+	assert.True(t, strings.Contains(payload.HTML, `<a href="?ref=v1&amp;theme=dark">View file</a>`))
+
+	status, _ := getMd("diff=missing")
+	assert.Equal(t, status, http.StatusNotFound)
+
+	rec := httptest.NewRecorder()
+	handler(filename, param, http.FileServer(http.Dir(dir)), watcher.NewDisabled()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?diff=--help", nil))
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+}
+
 func TestWrapHandler(t *testing.T) {
 	wrappedHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		fmt.Fprintln(w, "Hello")
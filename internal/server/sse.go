@@ -0,0 +1,102 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// sseClient is a Server-Sent Events stream, used by browsers that cannot
+// open a WebSocket, e.g. behind proxies that do not support upgrades.
+type sseClient struct {
+	send chan wsMessage
+	addr string
+}
+
+func (c *sseClient) queue() chan wsMessage {
+	return c.send
+}
+
+func (c *sseClient) remoteAddr() string {
+	return c.addr
+}
+
+func sseHandler(broker *wsBroker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+
+		// the stream outlives the server write timeout
+		err := rc.SetWriteDeadline(time.Time{})
+		if err != nil {
+			slog.Debug("SSE set write deadline error", "remote_addr", r.RemoteAddr, "error", err)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		client := &sseClient{
+			send: make(chan wsMessage, 4),
+			addr: r.RemoteAddr,
+		}
+		client.send <- wsMessage{message: helloMessage}
+
+		broker.register <- client
+		defer func() {
+			broker.unregister <- client
+		}()
+
+		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case msg, ok := <-client.send:
+				if !ok {
+					slog.Debug("Broker closed the channel", "remote_addr", client.addr)
+
+					return
+				}
+
+				if msg.err != nil {
+					slog.Debug("Error received from broker", "remote_addr", client.addr, "error", msg.err)
+
+					return
+				}
+
+				_, err = fmt.Fprintf(w, "data: %s\n\n", msg.message)
+				if err == nil {
+					err = rc.Flush()
+				}
+
+				if err != nil {
+					slog.Debug("SSE write message error", "remote_addr", client.addr, "error", err)
+
+					return
+				}
+
+				if msg.close {
+					return
+				}
+
+			case <-ticker.C:
+				// comments keep intermediaries from closing an idle stream
+				_, err = fmt.Fprint(w, ": ping\n\n")
+				if err == nil {
+					err = rc.Flush()
+				}
+
+				if err != nil {
+					slog.Debug("SSE ping error", "remote_addr", client.addr, "error", err)
+
+					return
+				}
+
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
+)
+
+func readEvent(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+
+	for {
+		line, err := reader.ReadString('\n')
+		assert.Nil(t, err)
+
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			return strings.TrimSuffix(data, "\n")
+		}
+	}
+}
+
+func TestSSEHandler(t *testing.T) {
+	w := watcher.NewDisabled()
+	defer w.Stop()
+
+	broker := startBroker(w)
+
+	s := httptest.NewServer(sseHandler(broker))
+	defer s.Close()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, s.URL, nil)
+	assert.Nil(t, err)
+
+	res, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	assert.Equal(t, res.StatusCode, http.StatusOK)
+	assert.Equal(t, res.Header.Get("Content-Type"), "text/event-stream")
+
+	reader := bufio.NewReader(res.Body)
+	assert.Equal(t, readEvent(t, reader), "hello 1")
+
+	w.MessageCh <- watcher.ReloadMessage
+
+	assert.Equal(t, readEvent(t, reader), expectedReloadMsg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	shutdownErr := make(chan error, 1)
+
+	go func() {
+		shutdownErr <- broker.shutdown(ctx)
+	}()
+
+	assert.Equal(t, readEvent(t, reader), string(shutdownMessage))
+	assert.Nil(t, <-shutdownErr)
+}
@@ -1,9 +1,11 @@
 /*jslint browser,long,fart,indent2*/
-/*global alert,console,window,WebSocket,JSON*/
+/*global alert,console,EventSource,sessionStorage,setTimeout,window,WebSocket,JSON*/
 
 (function () {
   "use strict";
 
+  // Custom templates may predate some parameters
+  const basePath = window.Param.basePath || "";
   const mermaidQuery = "code.language-mermaid";
   const mermaidMinScale = 0.2;
   const mermaidMaxScale = 5;
@@ -13,13 +15,22 @@
   const geoJSONQuery = "code.language-geojson";
   const topoJSONQuery = "code.language-topojson";
   const mapQuery = `${geoJSONQuery}, ${topoJSONQuery}`;
+  const reconnectBaseDelay = 500;
+  const reconnectMaxDelay = 30000;
+  const restoreStateKey = "gfm-preview-restore-state";
+  // git revision requested with ?ref=, kept while navigating
+  const queryRef = new window.URLSearchParams(window.location.search).get("ref") || "";
   const copyIcon = `<svg class="copy-icon" aria-hidden="true" fill="none" height="18" shape-rendering="geometricPrecision" stroke="currentColor" stroke-linecap="round" stroke-linejoin="round" stroke-width="1.5" viewBox="0 0 24 24" width="18" style="color:"currentColor";"><path d="M8 17.929H6c-1.105 0-2-.912-2-2.036V5.036C4 3.91 4.895 3 6 3h8c1.105 0 2 .911 2 2.036v1.866m-6 .17h8c1.105 0 2 .91 2 2.035v10.857C20 21.09 19.105 22 18 22h-8c-1.105 0-2-.911-2-2.036V9.107c0-1.124.895-2.036 2-2.036z"></path></svg>`;
   const tickIcon = `<svg class="tick-icon" aria-hidden="true" fill="none" height="18" shape-rendering="geometricPrecision" stroke="currentColor" stroke-linecap="round" stroke-linejoin="round" stroke-width="1.5" viewBox="0 0 24 24" width="18" style="color: "currentColor";"><path d="M5 13l4 4L19 7"></path></svg>`;
   const expandIcon = `<svg class="expand-icon" aria-hidden="true" viewBox="0 0 1792 1792" width="14" height="14" fill="currentColor"><path d="M883 1056q0 13-10 23l-332 332 144 144q19 19 19 45t-19 45-45 19h-448q-26 0-45-19t-19-45v-448q0-26 19-45t45-19 45 19l144 144 332-332q10-10 23-10t23 10l114 114q10 10 10 23zm781-864v448q0 26-19 45t-45 19-45-19l-144-144-332 332q-10 10-23 10t-23-10l-114-114q-10-10-10-23t10-23l332-332-144-144q-19-19-19-45t19-45 45-19h448q26 0 45 19t19 45z"></path></svg>`;
   let diagramMediaQuery;
+  let hasConnected = false;
   let loadMarkdownRequest = 0;
   let overlayCleanup;
+  let overlayDiagram;
   let overlayEl;
+  let preferEventSource = false;
+  let reconnectAttempts = 0;
 
   async function loadMermaid(isLight) {
     const theme = (
@@ -107,6 +118,10 @@
     if (overlayCleanup) {
       overlayCleanup();
     }
+    // Remember which diagram is open, so it can be reopened after a reload
+    overlayDiagram = Array.from(document.querySelectorAll(mermaidQuery)).findIndex(
+      (element) => element.contains(originalSvg)
+    );
     if (!overlayEl) {
       overlayEl = document.createElement("div");
       overlayEl.classList.add("mermaid-overlay");
@@ -167,6 +182,7 @@
       document.removeEventListener("keydown", onKeyDown);
       panZoom.detach();
       overlayCleanup = null;
+      overlayDiagram = undefined;
     };
     resetBtn.addEventListener("click", () => {
       panZoom.state.scale = initScale;
@@ -179,6 +195,20 @@
     overlayCleanup = closeOverlay;
   }
 
+  function reopenMermaidOverlay(index) {
+    const element = document.querySelectorAll(mermaidQuery)[index];
+    const svg = (
+      element
+      ? element.querySelector("svg")
+      : null
+    );
+    if (svg) {
+      openMermaidOverlay(svg);
+    } else if (overlayCleanup) {
+      overlayCleanup();
+    }
+  }
+
   function setupMermaidPanZoom() {
     document.querySelectorAll(mermaidQuery).forEach((element) => {
       const svg = element.querySelector("svg");
@@ -446,12 +476,57 @@
     renderMaps();
   }
 
+  function currentPath() {
+    return decodeURIComponent(
+      window.location.pathname.slice(basePath.length + 1)
+    );
+  }
+
+  // Tabs of the landing page show another document than the README
+  function documentPath() {
+    return window.Param.documentPath || currentPath();
+  }
+
+  function withRef(url) {
+    const target = new window.URL(url, window.location.href);
+    if (
+      target.origin !== window.location.origin
+      || target.pathname.startsWith(`${basePath}/static/`)
+      || target.pathname.startsWith(`${basePath}/__/`)
+      || target.searchParams.has("ref")
+    ) {
+      return url;
+    }
+
+    target.searchParams.set("ref", queryRef);
+    return target.href;
+  }
+
+  // Links and images of the revision being viewed also come from it
+  function keepRef(element) {
+    if (!queryRef) {
+      return;
+    }
+
+    element.querySelectorAll("a[href]").forEach((a) => {
+      if (!a.getAttribute("href").startsWith("#")) {
+        a.href = withRef(a.href);
+      }
+    });
+    element.querySelectorAll("img[src], audio[src], video[src], iframe[src]").forEach((media) => {
+      media.src = withRef(media.src);
+    });
+  }
+
   async function loadMarkdown() {
     const requestId = loadMarkdownRequest + 1;
     loadMarkdownRequest = requestId;
 
+    // the page query also selects the revisions and links back to the page
+    const query = new window.URLSearchParams(window.location.search);
+    query.set("path", documentPath());
     const response = await fetch(
-      `/__/md?path=${encodeURIComponent(decodeURIComponent(window.location.pathname.slice(1)))}`,
+      `${basePath}/__/md?${query}`,
       {cache: "no-store"}
     );
     const result = await response.json();
@@ -463,6 +538,7 @@
 
     const markdownBody = document.getElementById("markdown-body");
     markdownBody.innerHTML = result.html;
+    keepRef(markdownBody);
 
     const markdownTitle = document.getElementById("markdown-title");
     markdownTitle.innerHTML = result.title;
@@ -472,6 +548,151 @@
     await renderDiagrams();
     await typesetMathJax();
     addCopyButtons();
+    setupTableFiles();
+    highlightSourceLines(false);
+
+    if (overlayDiagram !== undefined && overlayDiagram >= 0) {
+      reopenMermaidOverlay(overlayDiagram);
+    }
+  }
+
+  function compareCells(a, b) {
+    const numberA = Number(a);
+    const numberB = Number(b);
+    if (a !== "" && b !== "" && !Number.isNaN(numberA) && !Number.isNaN(numberB)) {
+      return numberA - numberB;
+    }
+    return a.localeCompare(b, undefined, {numeric: true});
+  }
+
+  function sortTable(table, column, header) {
+    const ascending = header.getAttribute("aria-sort") !== "ascending";
+    const tbody = table.tBodies[0];
+    const rows = Array.from(tbody.rows);
+
+    rows.sort((a, b) => {
+      const order = compareCells(a.cells[column].textContent, b.cells[column].textContent);
+      return (
+        ascending
+        ? order
+        : -order
+      );
+    });
+
+    table.querySelectorAll("thead th").forEach((th) => th.removeAttribute("aria-sort"));
+    header.setAttribute("aria-sort", (
+      ascending
+      ? "ascending"
+      : "descending"
+    ));
+    rows.forEach((row) => tbody.appendChild(row));
+  }
+
+  function filterTable(table, query) {
+    const needle = query.trim().toLowerCase();
+
+    Array.from(table.tBodies[0].rows).forEach((row) => {
+      // Skip the row number
+      const text = Array.from(row.cells).slice(1).map((cell) => cell.textContent).join("\n");
+      row.hidden = needle !== "" && !text.toLowerCase().includes(needle);
+    });
+  }
+
+  function setupTableFiles() {
+    document.querySelectorAll(".markdown-body .table-file").forEach((container) => {
+      const table = container.querySelector("table.table-file-data");
+      if (!table || container.querySelector(".table-file-search")) {
+        return;
+      }
+
+      const search = document.createElement("input");
+      search.type = "search";
+      search.classList.add("table-file-search");
+      search.placeholder = "Search this file...";
+      search.setAttribute("aria-label", "Search this file");
+      search.addEventListener("input", () => filterTable(table, search.value));
+      container.insertBefore(search, table);
+
+      table.querySelectorAll("thead th").forEach((th, column) => {
+        th.classList.add("table-file-sortable");
+        th.addEventListener("click", () => sortTable(table, column, th));
+      });
+    });
+  }
+
+  function selectedSourceLines() {
+    const match = (/^#L(\d+)(?:-L(\d+))?$/).exec(window.location.hash);
+    if (!match) {
+      return undefined;
+    }
+
+    const first = Number(match[1]);
+    const last = Number(match[2] || match[1]);
+    return {end: Math.max(first, last), start: Math.min(first, last)};
+  }
+
+  function highlightSourceLines(scroll) {
+    const source = document.querySelector(".markdown-body .source-file");
+    if (!source) {
+      return;
+    }
+
+    source.querySelectorAll(".line.highlighted-line").forEach((line) => {
+      line.classList.remove("highlighted-line");
+    });
+
+    const selected = selectedSourceLines();
+    if (!selected) {
+      return;
+    }
+
+    // The range comes from the URL and may go past the end of the file
+    const last = Math.min(selected.end, source.querySelectorAll(".line").length);
+    let line = selected.start;
+    while (line <= last) {
+      const number = document.getElementById(`L${line}`);
+      if (number) {
+        number.parentElement.classList.add("highlighted-line");
+      }
+      line += 1;
+    }
+
+    const first = document.getElementById(`L${selected.start}`);
+    if (scroll && first) {
+      first.scrollIntoView({block: "center"});
+    }
+  }
+
+  function selectSourceLine(e) {
+    const link = e.target.closest && e.target.closest(".source-file a.lnlinks");
+    if (!link) {
+      return;
+    }
+
+    e.preventDefault();
+
+    // Shift-click extends the selection like GitHub
+    const line = Number(link.getAttribute("href").slice(2));
+    const selected = selectedSourceLines();
+    let hash = `#L${line}`;
+    if (e.shiftKey && selected) {
+      const start = Math.min(selected.start, line);
+      const end = Math.max(selected.start, line);
+      hash = (
+        start === end
+        ? `#L${start}`
+        : `#L${start}-L${end}`
+      );
+    }
+
+    window.history.pushState(null, "", hash);
+    highlightSourceLines(false);
+  }
+
+  function toggleImageZoom(e) {
+    if (e.target.matches && e.target.matches(".media-file-zoomable")) {
+      e.target.classList.toggle("is-zoomed");
+    }
   }
 
   async function typesetMathJax() {
@@ -491,6 +712,13 @@
     }
   }
 
+  function codeText(code) {
+    // Line numbers are not part of the code
+    const clone = code.cloneNode(true);
+    clone.querySelectorAll(".ln, .lnt").forEach((element) => element.remove());
+    return clone.textContent;
+  }
+
   function addCopyButtons() {
     document.querySelectorAll(".markdown-body pre").forEach((pre) => {
       if (pre.querySelector(".copy-button")) {
@@ -510,7 +738,7 @@
       pre.appendChild(button);
 
       button.addEventListener("click", () => {
-        const copyContent = pre.getAttribute("data-copy-content") || code.textContent;
+        const copyContent = pre.getAttribute("data-copy-content") || codeText(code);
         navigator.clipboard.writeText(copyContent).then(() => {
           button.innerHTML = tickIcon;
           setTimeout(() => {
@@ -523,6 +751,186 @@
     });
   }
 
+  function showStatus(message) {
+    const status = document.getElementById("preview-status");
+    // Custom templates may not have a status bar
+    if (!status) {
+      return;
+    }
+    status.textContent = message;
+    status.hidden = false;
+  }
+
+  function hideStatus() {
+    const status = document.getElementById("preview-status");
+    if (status) {
+      status.hidden = true;
+    }
+  }
+
+  function setConnectionState(state, text) {
+    const indicator = document.getElementById("connection-status");
+    if (!indicator) {
+      return;
+    }
+    indicator.setAttribute("data-state", state);
+    indicator.textContent = text;
+  }
+
+  function reloadPage() {
+    // Restored by restoreState() once the page loads again
+    sessionStorage.setItem(restoreStateKey, JSON.stringify({
+      diagram: overlayDiagram,
+      path: window.location.pathname,
+      scrollY: window.scrollY
+    }));
+    window.location.reload();
+  }
+
+  function restoreState() {
+    const saved = sessionStorage.getItem(restoreStateKey);
+    if (!saved) {
+      return;
+    }
+    sessionStorage.removeItem(restoreStateKey);
+    const state = JSON.parse(saved);
+    if (state.path !== window.location.pathname) {
+      return;
+    }
+    window.scrollTo(0, state.scrollY);
+    if (state.diagram !== undefined && state.diagram >= 0) {
+      reopenMermaidOverlay(state.diagram);
+    }
+  }
+
+  function reloadCustomCSS() {
+    const link = document.getElementById("custom-css");
+    if (link) {
+      link.href = `${basePath}/__/custom.css?t=${Date.now()}`;
+    }
+  }
+
+  async function toggleTask(checkbox) {
+    checkbox.disabled = true;
+    try {
+      const response = await fetch(`${basePath}/__/task`, {
+        body: JSON.stringify({
+          checked: checkbox.checked,
+          line: Number(checkbox.getAttribute("data-task-line")),
+          path: documentPath()
+        }),
+        headers: {"Content-Type": "application/json"},
+        method: "POST"
+      });
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+      // With live reload enabled the file watcher refreshes the preview
+      if (!window.Param.reload) {
+        await loadMarkdown();
+      }
+    } catch (error) {
+      console.error("Failed to toggle task:", error);
+      checkbox.checked = !checkbox.checked;
+    } finally {
+      checkbox.disabled = false;
+    }
+  }
+
+  function reloadPreview() {
+    // For directory mode, do a full page reload
+    // For markdown view, reload just the markdown content
+    if (window.Param.isDirectoryMode) {
+      reloadPage();
+    } else {
+      reloadCustomCSS();
+      loadMarkdown();
+    }
+  }
+
+  function onHello(version) {
+    reconnectAttempts = 0;
+    setConnectionState("connected", "Connected");
+    hideStatus();
+    if (window.Param.protocolVersion !== undefined && version !== window.Param.protocolVersion) {
+      // The server changed, and this page may not be compatible with it
+      console.log(`Protocol version changed to ${version}, reloading page`);
+      reloadPage();
+    } else if (hasConnected) {
+      // Changes may have been missed while disconnected
+      console.log("Reconnected, reloading");
+      reloadPreview();
+    }
+    hasConnected = true;
+  }
+
+  function onMessage(e) {
+    const [command, argument] = e.data.split(" ");
+    if (command === "hello") {
+      onHello(Number(argument));
+    } else if (command === "shutdown") {
+      console.log("Server stopped!");
+      setConnectionState("stopped", "Stopped");
+      showStatus("Preview stopped, changes will no longer be shown");
+    } else if (command === "reload") {
+      console.log("Reload page!");
+      reloadPreview();
+    }
+  }
+
+  function connect() {
+    const useEventSource = preferEventSource;
+    let opened = false;
+    const onClose = (reason) => {
+      if (!opened) {
+        // WebSockets may be blocked by a proxy, so alternate with
+        // Server-Sent Events until one of them works
+        preferEventSource = !useEventSource;
+      }
+      const delay = Math.min(
+        reconnectMaxDelay,
+        reconnectBaseDelay * (2 ** reconnectAttempts)
+      );
+      reconnectAttempts += 1;
+      console.log(`Connection closed (${reason}), reconnecting in ${delay}ms`);
+      const indicator = document.getElementById("connection-status");
+      if (indicator && indicator.getAttribute("data-state") !== "stopped") {
+        setConnectionState("reconnecting", "Reconnecting…");
+      }
+      setTimeout(connect, delay);
+    };
+
+    if (useEventSource) {
+      const source = new EventSource(`${basePath}/__/events`);
+      source.onopen = () => {
+        opened = true;
+      };
+      source.onmessage = onMessage;
+      source.onerror = () => {
+        // Reconnect with our own backoff instead of the browser's
+        source.close();
+        onClose("event stream error");
+      };
+    } else {
+      const protocol = (
+        window.location.protocol === "https:"
+        ? "wss:"
+        : "ws:"
+      );
+      // The page host, since a reverse proxy may pass another one to the server
+      const conn = new WebSocket(
+        `${protocol}//${window.location.host}${basePath}/ws`
+      );
+      conn.onopen = () => {
+        opened = true;
+        conn.send("Ping");
+      };
+      conn.onerror = (e) => console.log(`Connection error: ${e}`);
+      conn.onmessage = onMessage;
+      conn.onclose = (e) => onClose(e.code);
+    }
+  }
+
   function updateHeadingsList(headingsHTML, hasHeadings) {
     const details = document.getElementById("heading-list");
     const list = document.getElementById("headings-tree");
@@ -545,27 +953,29 @@
 
   (async function () {
     // Only load markdown initially if not in directory index mode
+    keepRef(document);
+
     if (!window.Param.isDirectoryIndex) {
       await loadMarkdown();
     }
 
-    if (window.Param.reload) {
-      const conn = new WebSocket(`ws://${window.Param.host}/ws`);
-      conn.onopen = () => conn.send("Ping");
-      conn.onerror = (e) => console.log(`Connection error: ${e}`);
-      conn.onclose = (e) => console.log(`Connection closed: ${e}`);
-      conn.onmessage = (e) => {
-        if (e.data === "reload") {
-          console.log("Reload page!");
-          // For directory index view, do a full page reload
-          // For markdown view, reload just the markdown content
-          if (window.Param.isDirectoryMode) {
-            window.location.reload();
-          } else {
-            loadMarkdown();
-          }
+    if (window.Param.interactiveTasks) {
+      document.addEventListener("change", (e) => {
+        if (e.target.matches(".markdown-body input[data-task-line]")) {
+          toggleTask(e.target);
         }
-      };
+      });
+    }
+
+    document.addEventListener("click", selectSourceLine);
+    document.addEventListener("click", toggleImageZoom);
+    window.addEventListener("hashchange", () => highlightSourceLines(true));
+    highlightSourceLines(true);
+
+    restoreState();
+
+    if (window.Param.reload) {
+      connect();
     }
   }());
 }());
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/app"
+)
+
+const maxTaskRequestSize = 1 << 16
+
+var errTaskStdin = errors.New("tasks cannot be toggled when reading from stdin")
+
+// taskHandler toggles a task list checkbox in the Markdown source file. The
+// file watcher then triggers the usual reload of the preview.
+func taskHandler(filename string, param *Param) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		// Cross-site pages can only post simple content types without a CORS
+		// preflight
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" {
+			http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+
+			return
+		}
+
+		var req taskRequestJSON
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTaskRequestSize)).Decode(&req)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid task request: %v", err), http.StatusBadRequest)
+
+			return
+		}
+
+		err = toggleTask(filename, req, param)
+		if err != nil {
+			slog.Error("Error while toggling task", "path", req.Path, "line", req.Line, "error", err)
+			writeTaskError(w, err)
+
+			return
+		}
+
+		slog.Info("Task toggled", "path", req.Path, "line", req.Line, "checked", req.Checked)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func toggleTask(filename string, req taskRequestJSON, param *Param) error {
+	if param.IsDirectoryMode {
+		return toggleRootTask(param.DirectoryRoot, req, param)
+	}
+
+	if param.UseStdin && filename == "" {
+		return errTaskStdin
+	}
+
+	root, err := os.OpenRoot(filepath.Dir(filename))
+	if err != nil {
+		return fmt.Errorf("directory root open error: %w", err)
+	}
+	defer root.Close()
+
+	if req.Path == "" {
+		req.Path = filepath.Base(filename)
+	}
+
+	return toggleRootTask(root, req, param)
+}
+
+func toggleRootTask(root *os.Root, req taskRequestJSON, param *Param) error {
+	if root == nil {
+		return errNoDirectoryRoot
+	}
+
+	normalizedPath, ok := normalizeRootPath(req.Path)
+	if !ok {
+		return fmt.Errorf("%w: %s", app.ErrFileNotFound, req.Path)
+	}
+
+	file, _, err := resolveRootMarkdownTarget(root.FS(), normalizedPath, param)
+	if err != nil {
+		return err
+	}
+
+	info, err := root.Stat(file)
+	if err != nil {
+		return fmt.Errorf("root stat error: %w", err)
+	}
+
+	source, err := root.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("root read error: %w", err)
+	}
+
+	result, err := app.ToggleTask(source, req.Line, req.Checked)
+	if err != nil {
+		return fmt.Errorf("toggle task error: %w", err)
+	}
+
+	err = root.WriteFile(file, result, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("root write error: %w", err)
+	}
+
+	return nil
+}
+
+func writeTaskError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrFileNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, app.ErrTaskNotFound):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errTaskStdin):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
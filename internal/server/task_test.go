@@ -0,0 +1,123 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func postTask(t *testing.T, handler http.Handler, body string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/__/task", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	return rec.Result()
+}
+
+func TestTaskHandlerTogglesSingleFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "CHECKLIST.md")
+	err := os.WriteFile(filename, []byte("# Release\n\n- [ ] Tag\n- [x] Build\n"), 0o600)
+	assert.Nil(t, err)
+
+	handler := taskHandler(filename, &Param{InteractiveTasks: true})
+
+	res := postTask(t, handler, `{"path":"","line":3,"checked":true}`)
+	defer res.Body.Close()
+
+	assert.Equal(t, res.StatusCode, http.StatusNoContent)
+
+	res = postTask(t, handler, `{"path":"","line":4,"checked":false}`)
+	defer res.Body.Close()
+
+	assert.Equal(t, res.StatusCode, http.StatusNoContent)
+
+	b, err := os.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, string(b), "# Release\n\n- [x] Tag\n- [ ] Build\n")
+}
+
+func TestTaskHandlerTogglesInDirectoryMode(t *testing.T) {
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "docs"), 0o755)
+	assert.Nil(t, err)
+
+	filename := filepath.Join(dir, "docs", "README.md")
+	err = os.WriteFile(filename, []byte("- [ ] Task\r\n"), 0o600)
+	assert.Nil(t, err)
+
+	root, err := os.OpenRoot(dir)
+	assert.Nil(t, err)
+
+	defer root.Close()
+
+	param := &Param{
+		InteractiveTasks: true,
+		IsDirectoryMode:  true,
+		DirectoryPath:    dir,
+		DirectoryRoot:    root,
+	}
+
+	res := postTask(t, taskHandler("", param), `{"path":"docs/","line":1,"checked":true}`)
+	defer res.Body.Close()
+
+	assert.Equal(t, res.StatusCode, http.StatusNoContent)
+
+	b, err := os.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, string(b), "- [x] Task\r\n")
+}
+
+func TestTaskHandlerErrors(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "CHECKLIST.md")
+	err := os.WriteFile(filename, []byte("# Not a task\n"), 0o600)
+	assert.Nil(t, err)
+
+	handler := taskHandler(filename, &Param{InteractiveTasks: true})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"Invalid JSON", `{`, http.StatusBadRequest},
+		{"Not a task line", `{"line":1,"checked":true}`, http.StatusConflict},
+		{"Escaping path", `{"path":"../CHECKLIST.md","line":1,"checked":true}`, http.StatusNotFound},
+		{"Missing file", `{"path":"missing.md","line":1,"checked":true}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := postTask(t, handler, tt.body)
+			defer res.Body.Close()
+
+			assert.Equal(t, res.StatusCode, tt.wantStatus)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/__/task", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusMethodNotAllowed)
+
+	req = httptest.NewRequest(http.MethodPost, "/__/task", strings.NewReader(`{"line":1,"checked":true}`))
+	req.Header.Set("Content-Type", "text/plain")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusUnsupportedMediaType)
+
+	res := postTask(t, taskHandler("", &Param{InteractiveTasks: true, UseStdin: true}), `{"line":1,"checked":true}`)
+	defer res.Body.Close()
+
+	assert.Equal(t, res.StatusCode, http.StatusBadRequest)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/big"
+	"net"
+	"slices"
+	"time"
+)
+
+const selfSignedValidity = 365 * 24 * time.Hour
+
+var errTLSKeyPair = errors.New("both --tls-cert and --tls-key must be given")
+
+// tlsConfig returns the TLS configuration for the server, loading the
+// certificate from param.TLSCert and param.TLSKey or, without them,
+// generating a self-signed one valid for names.
+func (param *Param) tlsConfig(names map[string]bool) (*tls.Config, error) {
+	if (param.TLSCert == "") != (param.TLSKey == "") {
+		return nil, errTLSKeyPair
+	}
+
+	var (
+		cert tls.Certificate
+		err  error
+	)
+
+	if param.TLSCert != "" {
+		cert, err = tls.LoadX509KeyPair(param.TLSCert, param.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("TLS certificate load error: %w", err)
+		}
+	} else {
+		cert, err = selfSignedCertificate(names)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Warn("Using a self-signed certificate, browsers will ask to trust it on first access")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// selfSignedCertificate generates an in-memory certificate for names, which
+// may be DNS names or IP addresses.
+func selfSignedCertificate(names map[string]bool) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("TLS key generation error: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("TLS serial number generation error: %w", err)
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"gh-gfm-preview"}, CommonName: "gh-gfm-preview"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		switch ip := net.ParseIP(name); {
+		case name == anyHost:
+			continue
+		case ip != nil:
+			template.IPAddresses = append(template.IPAddresses, ip)
+		default:
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("TLS certificate generation error: %w", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+)
+
+func TestSelfSignedCertificate(t *testing.T) {
+	names := map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true, anyHost: true}
+
+	cert, err := selfSignedCertificate(names)
+	assert.Nil(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.Nil(t, err)
+
+	assert.DeepEqual(t, leaf.DNSNames, []string{"localhost"})
+	assert.Equal(t, len(leaf.IPAddresses), 2)
+	assert.Nil(t, leaf.VerifyHostname("localhost"))
+	assert.Nil(t, leaf.VerifyHostname("127.0.0.1"))
+	assert.NotNil(t, leaf.VerifyHostname("example.com"))
+}
+
+func TestTLSConfig(t *testing.T) {
+	_, err := (&Param{TLSCert: "cert.pem"}).tlsConfig(nil)
+	assert.NotNil(t, err)
+
+	_, err = (&Param{TLSCert: filepath.Join(t.TempDir(), "cert.pem"), TLSKey: "key.pem"}).tlsConfig(nil)
+	assert.NotNil(t, err)
+
+	config, err := (&Param{}).tlsConfig(map[string]bool{"127.0.0.1": true})
+	assert.Nil(t, err)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	s.TLS = config
+	s.StartTLS()
+
+	defer s.Close()
+
+	leaf, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	assert.Nil(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, s.URL, nil)
+	assert.Nil(t, err)
+
+	res, err := client.Do(req)
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	assert.Equal(t, res.StatusCode, http.StatusNoContent)
+}
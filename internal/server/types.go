@@ -4,26 +4,43 @@ import (
 	"html/template"
 	"net/http"
 	"os"
+	"time"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/app"
+	"github.com/thiagokokada/gh-gfm-preview/internal/git"
 )
 
 type TemplateParam struct {
-	Title            string
-	Body             template.HTML
-	HeadingsHTML     template.HTML
-	HasHeadings      bool
-	Host             string
-	Reload           bool
-	Mode             string
-	ShowBrowseButton bool
-	IsDirectoryMode  bool
-	IsDirectoryIndex bool
-	HasReadme        bool
-	DirectoryTitle   string
-	Files            []FileInfo
-	FileTree         []FileTreeItem
-	CurrentPath      string
-	ParentPath       string
-	BreadcrumbItems  []BreadcrumbItem
+	Title             string
+	Body              template.HTML
+	HeadingsHTML      template.HTML
+	HasHeadings       bool
+	InteractiveTasks  bool
+	Host              string
+	BasePath          string
+	Reload            bool
+	ProtocolVersion   int
+	Mode              string
+	CustomCSS         bool
+	CodeLightStyleURL string
+	CodeDarkStyleURL  string
+	ShowBrowseButton  bool
+	IsDirectoryMode   bool
+	IsDirectoryIndex  bool
+	HasReadme         bool
+	DirectoryTitle    string
+	Files             []FileInfo
+	FileTree          []FileTreeItem
+	CurrentPath       string
+	ParentPath        string
+	BreadcrumbItems   []BreadcrumbItem
+	Ref               string
+	HasGitStatus      bool
+	ChangedOnly       bool
+	LandingFiles      []LandingFileItem
+	LandingCommit     LandingCommit
+	LandingTabs       []LandingTab
+	DocumentPath      string
 }
 
 type Param struct {
@@ -38,15 +55,48 @@ type Param struct {
 	DirectoryListing               bool
 	DirectoryListingShowExtensions string
 	DirectoryListingTextExtensions string
+	Autolink                       bool
+	AutolinkRepository             string
+	InteractiveTasks               bool
+	CodeRenderers                  []string
+	CodeRendererTimeout            time.Duration
+	MarkupRenderers                []string
+	CustomCSS                      string
+	CustomTemplate                 string
+	CodeLightStyle                 string
+	CodeDarkStyle                  string
+	CodeLineNumbers                bool
+	ExitAfterDisconnect            time.Duration
+	ExitIdleTimeout                time.Duration
+	Token                          string
+	DisableToken                   bool
+	AllowedHosts                   []string
+	TLS                            bool
+	TLSCert                        string
+	TLSKey                         string
+	BasePath                       string
+	Version                        string
 	IsDirectoryMode                bool
 	DirectoryPath                  string
 	DirectoryRoot                  *os.Root
 	ReadmeFile                     string
+	Ref                            string
+	Wiki                           bool
+
+	codeRenderers   *app.CodeRenderers
+	markupRenderers *app.MarkupRenderers
+	template        *template.Template
+	diffRef         string
+	fileQuery       string
+	wikiPages       *app.WikiPages
+	cache           *requestCache
+	commitCache     *git.CommitCache
 }
 
 type Server struct {
-	Host string
-	Port int
+	Host   string
+	Port   int
+	Socket string
 }
 
 type loggingResponseWriter struct {
@@ -61,6 +111,62 @@ type mdResponseJSON struct {
 	HasHeadings  bool   `json:"has_headings"`
 }
 
+type taskRequestJSON struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Checked bool   `json:"checked"`
+}
+
+type apiInfoJSON struct {
+	Version         string `json:"version"`
+	ProtocolVersion int    `json:"protocol_version"`
+	Root            string `json:"root"`
+	DirectoryMode   bool   `json:"directory_mode"`
+	Stdin           bool   `json:"stdin"`
+	Mode            string `json:"mode"`
+	ColorMode       string `json:"color_mode"`
+	Reload          bool   `json:"reload"`
+}
+
+type apiFileJSON struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	IsDir   bool   `json:"is_dir"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"mod_time"`
+	IsText  bool   `json:"is_text"`
+}
+
+type apiFilesJSON struct {
+	Path  string        `json:"path"`
+	Files []apiFileJSON `json:"files"`
+}
+
+type apiHeadingJSON struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+type apiHeadingsJSON struct {
+	Path     string           `json:"path"`
+	Headings []apiHeadingJSON `json:"headings"`
+}
+
+type apiRenderRequestJSON struct {
+	Markdown string `json:"markdown"`
+	Mode     string `json:"mode"`
+}
+
+type apiRenderJSON struct {
+	HTML     string           `json:"html"`
+	Headings []apiHeadingJSON `json:"headings"`
+}
+
+type apiErrorJSON struct {
+	Error string `json:"error"`
+}
+
 type markdownView struct {
 	HTML         string
 	HeadingsHTML string
@@ -74,11 +180,30 @@ type FileInfo struct {
 }
 
 type FileTreeItem struct {
+	Name      string
+	Path      string
+	IsDir     bool
+	IsBinary  bool
+	GitStatus git.FileStatus
+	Children  []FileTreeItem
+}
+
+type LandingFileItem struct {
+	FileTreeItem
+	Commit LandingCommit
+}
+
+type LandingCommit struct {
+	Subject  string
+	Hash     string
+	Date     string
+	DateTime string
+}
+
+type LandingTab struct {
+	Tab      string
 	Name     string
-	Path     string
-	IsDir    bool
-	IsBinary bool
-	Children []FileTreeItem
+	Selected bool
 }
 
 type BreadcrumbItem struct {
@@ -3,17 +3,16 @@ package server
 import (
 	"errors"
 	"log/slog"
-	"net"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/websocket"
-	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
 )
 
 const (
 	defaultPongWait   = 60 * time.Second
 	defaultPingPeriod = (defaultPongWait * 9) / 10 // must be less than pong wait
+	closeWait         = time.Second                // time for the client to acknowledge a close
 )
 
 var (
@@ -38,8 +37,28 @@ func (c *wsClient) cleanup(doneCh chan<- struct{}) {
 	doneCh <- struct{}{}
 }
 
-func (c *wsClient) remoteAddr() net.Addr {
-	return c.conn.UnderlyingConn().RemoteAddr()
+func (c *wsClient) queue() chan wsMessage {
+	return c.send
+}
+
+func (c *wsClient) remoteAddr() string {
+	return c.conn.RemoteAddr().String()
+}
+
+// close starts the WebSocket closing handshake. readPump returns once the
+// client acknowledges it, or after closeWait if it never does.
+func (c *wsClient) close() {
+	message := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server stopping")
+
+	err := c.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(closeWait))
+	if err != nil {
+		slog.Debug("WS close message error", "remote_addr", c.remoteAddr(), "error", err)
+	}
+
+	err = c.conn.SetReadDeadline(time.Now().Add(closeWait))
+	if err != nil {
+		slog.Warn("WS set read deadline error", "remote_addr", c.remoteAddr(), "error", err)
+	}
 }
 
 func (c *wsClient) readPump(doneCh chan<- struct{}) {
@@ -93,6 +112,12 @@ func (c *wsClient) writePump() {
 				return
 			}
 
+			if msg.close {
+				c.close()
+
+				return
+			}
+
 		case <-ticker.C:
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				// do nothing
@@ -104,37 +129,20 @@ func (c *wsClient) writePump() {
 	}
 }
 
-func wsHandler(watcher *watcher.Watcher) http.Handler {
-	broker := newBroker()
-	go broker.run()
-
-	// forward watcher reload signals to the broker
-	go func() {
-		for {
-			select {
-			case message := <-watcher.MessageCh:
-				broker.broadcast <- wsMessage{message: message}
-			case err := <-watcher.ErrorCh:
-				broker.broadcast <- wsMessage{err: err}
-			}
-		}
-	}()
-
-	go watcher.Watch()
-
+func wsHandler(broker *wsBroker) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			if errors.Is(err, websocket.HandshakeError{}) {
 				slog.Error(
 					"WS handshake error",
-					"remote_addr", conn.UnderlyingConn().RemoteAddr(),
+					"remote_addr", r.RemoteAddr,
 					"error", err,
 				)
 			} else {
 				slog.Debug(
 					"WS connection upgrade error",
-					"remote_addr", conn.UnderlyingConn().RemoteAddr(),
+					"remote_addr", r.RemoteAddr,
 					"error", err,
 				)
 			}
@@ -147,6 +155,7 @@ func wsHandler(watcher *watcher.Watcher) http.Handler {
 			conn:   conn,
 			send:   make(chan wsMessage, 4),
 		}
+		client.send <- wsMessage{message: helloMessage}
 
 		broker.register <- client
 
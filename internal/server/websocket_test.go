@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
@@ -28,13 +29,15 @@ func TestWriter(t *testing.T) {
 	w, err := watcher.Init(dir)
 	assert.Nil(t, err)
 
-	s := httptest.NewServer(wsHandler(w))
+	s := httptest.NewServer(wsHandler(startBroker(w)))
 
 	u := "ws" + strings.TrimPrefix(s.URL, "http")
 
 	ws, res, err := websocket.DefaultDialer.Dial(u, nil)
 	assert.Nil(t, err)
 
+	assertHelloMessage(t, ws)
+
 	<-time.After(50 * time.Millisecond) // XXX
 
 	defer ws.Close()
@@ -61,7 +64,7 @@ func TestConcurrentWrites(t *testing.T) {
 	watcher, err := watcher.Init(dir)
 	assert.Nil(t, err)
 
-	s := httptest.NewServer(wsHandler(watcher))
+	s := httptest.NewServer(wsHandler(startBroker(watcher)))
 	defer s.Close()
 
 	u := "ws" + strings.TrimPrefix(s.URL, "http")
@@ -72,6 +75,8 @@ func TestConcurrentWrites(t *testing.T) {
 	defer ws.Close()
 	defer res.Body.Close()
 
+	assertHelloMessage(t, ws)
+
 	<-time.After(50 * time.Millisecond)
 
 	errorChan := startConcurrentWrites(t, testFile, 100)
@@ -125,6 +130,17 @@ func waitForConcurrentWrites(t *testing.T, errorChan <-chan error) {
 	}
 }
 
+func assertHelloMessage(t *testing.T, ws *websocket.Conn) {
+	t.Helper()
+
+	err := ws.SetReadDeadline(time.Now().Add(3 * time.Second))
+	assert.Nil(t, err)
+
+	_, msg, err := ws.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, string(msg), "hello 1")
+}
+
 func assertReloadMessage(t *testing.T, ws *websocket.Conn) {
 	t.Helper()
 
@@ -151,7 +167,7 @@ func TestConcurrentWritesStress(t *testing.T) {
 	watcher, err := watcher.Init(dir)
 	assert.Nil(t, err)
 
-	s := httptest.NewServer(wsHandler(watcher))
+	s := httptest.NewServer(wsHandler(startBroker(watcher)))
 	defer s.Close()
 
 	u := "ws" + strings.TrimPrefix(s.URL, "http")
@@ -200,3 +216,43 @@ func TestConcurrentWritesStress(t *testing.T) {
 		}
 	}
 }
+
+func TestShutdownClosesClients(t *testing.T) {
+	w := watcher.NewDisabled()
+	broker := startBroker(w)
+
+	s := httptest.NewServer(wsHandler(broker))
+	defer s.Close()
+
+	u := "ws" + strings.TrimPrefix(s.URL, "http")
+
+	ws, res, err := websocket.DefaultDialer.Dial(u, nil)
+	assert.Nil(t, err)
+
+	defer ws.Close()
+	defer res.Body.Close()
+
+	assertHelloMessage(t, ws)
+
+	<-time.After(50 * time.Millisecond) // XXX
+
+	shutdownErr := make(chan error, 1)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		shutdownErr <- broker.shutdown(ctx)
+	}()
+
+	_, actual, err := ws.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, string(actual), string(shutdownMessage))
+
+	_, _, err = ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+
+	assert.Nil(t, <-shutdownErr)
+
+	w.Stop()
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/app"
+	"github.com/thiagokokada/gh-gfm-preview/internal/git"
+)
+
+var errWikiFile = errors.New("--wiki needs a directory to preview, not a single file")
+
+// isWikiDirectory reports whether dir is a checked out GitHub wiki, which
+// are cloned from <repository>.wiki.git into a <repository>.wiki directory.
+func isWikiDirectory(dir string) bool {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasSuffix(filepath.Base(abs), ".wiki")
+}
+
+// validateWiki fails fast if --wiki is given without a directory to preview,
+// since wiki links, sidebars and footers need the other pages.
+func validateWiki(param *Param) error {
+	if param.Wiki && !param.IsDirectoryMode {
+		return errWikiFile
+	}
+
+	return nil
+}
+
+// findReadmeFS finds the page shown for dir: the Home page of a wiki or its
+// README.
+func (param *Param) findReadmeFS(fsys fs.FS, dir string) (string, error) {
+	if param.Wiki {
+		home, err := app.FindWikiHomeFS(fsys, dir)
+		if err == nil {
+			return home, nil
+		}
+	}
+
+	readme, err := app.FindReadmeFS(fsys, dir)
+	if err != nil {
+		return "", fmt.Errorf("find readme error: %w", err)
+	}
+
+	return readme, nil
+}
+
+// wikiIndex returns the pages of the wiki in fsys, the previewed directory,
+// which are found once per request.
+func (param *Param) wikiIndex(fsys fs.FS) (*app.WikiPages, error) {
+	return cached(param, wikiKey{dir: param.DirectoryPath, ref: param.Ref}, func() (*app.WikiPages, error) {
+		pages, err := app.NewWikiPages(fsys)
+		if err != nil {
+			return nil, fmt.Errorf("wiki pages error: %w", err)
+		}
+
+		return pages, nil
+	})
+}
+
+// renderWikiView renders a wiki page like renderFileOrDiffView, with wiki
+// links and surrounded by the nearest _Sidebar and _Footer pages.
+func renderWikiView(fsys fs.FS, file, content string, readPrevious func(*Param) (string, error), param *Param) (markdownView, error) {
+	pages, err := param.wikiIndex(fsys)
+	if err != nil {
+		return markdownView{}, err
+	}
+
+	wikiParam := *param
+	wikiParam.wikiPages = pages
+
+	view, err := renderFileOrDiffView(file, content, readPrevious, &wikiParam)
+	if err != nil || !app.IsWikiPage(file) || app.IsWikiSpecialPage(file) {
+		return view, err
+	}
+
+	// the sidebar and footer aren't the file whose tasks can be toggled, nor
+	// are they compared between revisions
+	wikiParam.InteractiveTasks = false
+	wikiParam.diffRef = ""
+
+	sidebar, err := renderWikiSection(fsys, file, "_Sidebar", &wikiParam)
+	if err != nil {
+		return markdownView{}, err
+	}
+
+	footer, err := renderWikiSection(fsys, file, "_Footer", &wikiParam)
+	if err != nil {
+		return markdownView{}, err
+	}
+
+	if sidebar != "" {
+		view.HTML = `<div class="wiki-layout">` + "\n" +
+			`<div class="wiki-content">` + "\n" + view.HTML + "</div>\n" +
+			`<div class="wiki-sidebar">` + "\n" + sidebar + "</div>\n" +
+			"</div>\n"
+	}
+
+	if footer != "" {
+		view.HTML += `<div class="wiki-footer">` + "\n" + footer + "</div>\n"
+	}
+
+	return view, nil
+}
+
+// renderWikiSection renders the special page name nearest to file, or returns
+// an empty string if there is none.
+func renderWikiSection(fsys fs.FS, file, name string, param *Param) (string, error) {
+	section, ok := param.wikiPages.Nearest(path.Dir(file), name)
+	if !ok {
+		return "", nil
+	}
+
+	content, err := readRootMarkdown(fsys, section)
+	if err != nil {
+		return "", err
+	}
+
+	view, err := renderFileView(section, content, param)
+	if err != nil {
+		return "", err
+	}
+
+	return view.HTML, nil
+}
+
+// listWikiPages lists all the pages of the wiki by name.
+func listWikiPages(param *Param, status *git.Status) ([]FileTreeItem, error) {
+	fsys, err := param.directoryFS()
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := param.wikiIndex(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []FileTreeItem
+
+	for _, page := range pages.Pages() {
+		items = append(items, FileTreeItem{
+			Name:      page.Name,
+			Path:      page.Path,
+			GitStatus: status.Of(page.Path, false),
+		})
+	}
+
+	return items, nil
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thiagokokada/gh-gfm-preview/internal/assert"
+	"github.com/thiagokokada/gh-gfm-preview/internal/watcher"
+)
+
+func TestWikiMode(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "project.wiki")
+	files := map[string]string{
+		"Home.md":            "# Welcome\n\nSee [[Getting Started]] and [[the FAQ|faq]].\n",
+		"Getting-Started.md": "# Getting started\n\nBack [[Home]].\n",
+		"FAQ.md":             "# FAQ\n",
+		"_Sidebar.md":        "* [[Home]]\n* [[Missing Page]]\n",
+		"_Footer.md":         "Footer text\n",
+	}
+
+	for name, content := range files {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	param := &Param{DirectoryListingShowExtensions: ".md"}
+	filename, _, err := setupDirectoryMode(param, dir)
+	assert.Nil(t, err)
+	assert.True(t, param.Wiki)
+	assert.Equal(t, filename, filepath.Join(dir, "Home.md"))
+
+	root, err := os.OpenRoot(dir)
+	assert.Nil(t, err)
+
+	defer root.Close()
+
+	param.DirectoryRoot = root
+
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		handler("", param, http.FileServer(http.Dir(dir)), watcher.NewDisabled()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, rec.Code, http.StatusOK)
+
+		return rec.Body.String()
+	}
+
+	body := get("/")
+	assert.True(t, strings.Contains(body, `<a href="/Getting-Started.md">Getting Started</a>`))
+	assert.True(t, strings.Contains(body, `<a href="/FAQ.md">the FAQ</a>`))
+	assert.True(t, strings.Contains(body, `<div class="wiki-sidebar">`))
+	assert.True(t, strings.Contains(body, `class="wiki-link-missing">Missing Page</a>`))
+	assert.True(t, strings.Contains(body, `<div class="wiki-footer">`+"\n<p>Footer text</p>"))
+
+	// Special pages are shown as they are
+	body = get("/_Footer.md")
+	assert.False(t, strings.Contains(body, `<div class="wiki-footer">`))
+
+	body = get("/?view=index")
+	assert.True(t, strings.Contains(body, `<span class="file-name">Getting Started</span>`))
+	assert.True(t, strings.Contains(body, `<span class="file-name">FAQ</span>`))
+	assert.False(t, strings.Contains(body, `<span class="file-name">_Sidebar.md</span>`))
+}
+
+func TestWikiNeedsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Home.md"), []byte("# Home\n"), 0o600))
+
+	param := &Param{Filename: dir, Wiki: true}
+	_, _, err := resolveFileAndDir(param)
+	assert.Nil(t, err)
+	assert.True(t, param.IsDirectoryMode)
+	assert.Nil(t, validateWiki(param))
+
+	param = &Param{Filename: filepath.Join(dir, "Home.md"), Wiki: true}
+	_, _, err = resolveFileAndDir(param)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(validateWiki(param), errWikiFile))
+}
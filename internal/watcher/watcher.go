@@ -29,6 +29,7 @@ type Watcher struct {
 
 	watcher     *fsnotify.Watcher
 	watchedDirs sync.Map
+	stopOnce    sync.Once
 }
 
 func NewDisabled() *Watcher {
@@ -83,6 +84,18 @@ func (w *Watcher) Close() error {
 	return nil
 }
 
+// Stop closes DoneCh, ending Watch and anything else waiting on it. It is safe
+// to call Stop more than once.
+func (w *Watcher) Stop() {
+	if w == nil {
+		return
+	}
+
+	w.stopOnce.Do(func() {
+		close(w.DoneCh)
+	})
+}
+
 func (w *Watcher) AddDirectory(dir string) error {
 	if w == nil {
 		return ErrWatcherNotInitialized
@@ -118,7 +131,10 @@ func (w *Watcher) Watch() {
 	debouncer := newReloadDebouncer(func(path string) {
 		slog.Info("Change detected, refreshing", "path", path)
 
-		w.MessageCh <- ReloadMessage
+		select {
+		case w.MessageCh <- ReloadMessage:
+		case <-w.DoneCh:
+		}
 	})
 
 	for {
@@ -132,7 +148,11 @@ func (w *Watcher) Watch() {
 		case err := <-w.watcher.Errors:
 			slog.Error("FS watcher error", "error", err)
 
-			w.ErrorCh <- err
+			select {
+			case w.ErrorCh <- err:
+			case <-w.DoneCh:
+				return
+			}
 		case <-w.DoneCh:
 			return
 		}
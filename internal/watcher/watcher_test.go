@@ -303,3 +303,29 @@ func TestInit_ReturnsErrorForMissingPath(t *testing.T) {
 	assert.Nil(t, w)
 	assert.True(t, err != nil)
 }
+
+func TestStop_EndsWatch(t *testing.T) {
+	dir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	w, err := Init(dir)
+	assert.Nil(t, err)
+
+	defer w.Close()
+
+	done := make(chan struct{})
+
+	go func() {
+		w.Watch()
+		close(done)
+	}()
+
+	w.Stop()
+	w.Stop() // must not panic
+
+	select {
+	case <-done:
+	case <-time.After(1 * time.Second):
+		t.Fatal("timeout waiting for Watch to return")
+	}
+}